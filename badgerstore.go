@@ -0,0 +1,190 @@
+package objst
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// badgerStore is a store backed by badger.
+type badgerStore struct {
+	db *badger.DB
+}
+
+func openBadgerStore(opts badger.Options) (*badgerStore, error) {
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &badgerStore{db: db}, nil
+}
+
+// badgerErr translates the errors of badger
+// into the errors of the store interface.
+func badgerErr(err error) error {
+	switch {
+	case errors.Is(err, errKeyNotFound), errors.Is(err, errTxnTooBig), errors.Is(err, errConflict):
+		// already translated
+		return err
+	case errors.Is(err, badger.ErrKeyNotFound):
+		return fmt.Errorf("%w: %w", errKeyNotFound, err)
+	case errors.Is(err, badger.ErrTxnTooBig):
+		return fmt.Errorf("%w: %w", errTxnTooBig, err)
+	case errors.Is(err, badger.ErrConflict):
+		return fmt.Errorf("%w: %w", errConflict, err)
+	}
+	return err
+}
+
+func (s *badgerStore) View(fn func(txn storeTxn) error) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		return fn(badgerTxn{txn})
+	})
+}
+
+func (s *badgerStore) Update(fn func(txn storeTxn) error) error {
+	err := s.db.Update(func(txn *badger.Txn) error {
+		return fn(badgerTxn{txn})
+	})
+	return badgerErr(err)
+}
+
+func (s *badgerStore) NewTxn(update bool) storeTxn {
+	return badgerTxn{s.db.NewTransaction(update)}
+}
+
+func (s *badgerStore) NewBatch() storeBatch {
+	return badgerBatch{s.db.NewWriteBatch()}
+}
+
+func (s *badgerStore) Close() error {
+	return s.db.Close()
+}
+
+type badgerTxn struct {
+	txn *badger.Txn
+}
+
+func (t badgerTxn) Get(key []byte) ([]byte, error) {
+	item, err := t.txn.Get(key)
+	if err != nil {
+		return nil, badgerErr(err)
+	}
+	return item.ValueCopy(nil)
+}
+
+func (t badgerTxn) Set(key, val []byte) error {
+	return badgerErr(t.txn.Set(key, val))
+}
+
+func (t badgerTxn) Delete(key []byte) error {
+	return badgerErr(t.txn.Delete(key))
+}
+
+func (t badgerTxn) Iterate(prefix []byte, keysOnly bool) storeIterator {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	opts.PrefetchValues = !keysOnly
+	return badgerIterator{it: t.txn.NewIterator(opts)}
+}
+
+func (t badgerTxn) IterateReverse(prefix []byte, keysOnly bool) storeIterator {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	opts.PrefetchValues = !keysOnly
+	opts.Reverse = true
+	return badgerIterator{it: t.txn.NewIterator(opts), prefix: prefix, reverse: true}
+}
+
+func (t badgerTxn) Commit() error {
+	return badgerErr(t.txn.Commit())
+}
+
+func (t badgerTxn) Discard() {
+	t.txn.Discard()
+}
+
+type badgerIterator struct {
+	it      *badger.Iterator
+	prefix  []byte
+	reverse bool
+}
+
+func (i badgerIterator) Rewind() {
+	if i.reverse {
+		// badger rewinds reverse iterators to the prefix
+		// itself which is less than all keys of the prefix.
+		i.it.Seek(append(bytes.Clone(i.prefix), 0xff))
+		return
+	}
+	i.it.Rewind()
+}
+
+func (i badgerIterator) Seek(key []byte) {
+	i.it.Seek(key)
+}
+
+func (i badgerIterator) Valid() bool {
+	return i.it.Valid()
+}
+
+func (i badgerIterator) Next() {
+	i.it.Next()
+}
+
+func (i badgerIterator) Key() []byte {
+	return i.it.Item().KeyCopy(nil)
+}
+
+func (i badgerIterator) Value() ([]byte, error) {
+	return i.it.Item().ValueCopy(nil)
+}
+
+func (i badgerIterator) Close() {
+	i.it.Close()
+}
+
+type badgerBatch struct {
+	wb *badger.WriteBatch
+}
+
+func (b badgerBatch) Set(key, val []byte) error {
+	return badgerErr(b.wb.Set(key, val))
+}
+
+func (b badgerBatch) Delete(key []byte) error {
+	return badgerErr(b.wb.Delete(key))
+}
+
+func (b badgerBatch) Flush() error {
+	return badgerErr(b.wb.Flush())
+}
+
+func (b badgerBatch) Cancel() {
+	b.wb.Cancel()
+}
+
+// diskSize returns the size of the tables and
+// value logs of the badger store in dir.
+func diskSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch filepath.Ext(path) {
+		case ".sst", ".vlog":
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
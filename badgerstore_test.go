@@ -0,0 +1,87 @@
+package objst
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func newTestStore(t *testing.T) store {
+	opts := testBucketOptions(nil)
+	s, err := openBadgerStore(opts.toMemoryBadgerOpts(StoreOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		s.Close()
+	})
+	return s
+}
+
+func TestBadgerStoreKeyNotFound(t *testing.T) {
+	s := newTestStore(t)
+	err := s.View(func(txn storeTxn) error {
+		_, err := txn.Get([]byte("missing"))
+		return err
+	})
+	if !errors.Is(err, errKeyNotFound) {
+		t.Fatalf("missing key should not be found. Got: %v", err)
+	}
+	if !errors.Is(err, badger.ErrKeyNotFound) {
+		t.Fatalf("cause of the store should be kept. Got: %v", err)
+	}
+}
+
+func TestBadgerStoreIterate(t *testing.T) {
+	s := newTestStore(t)
+	err := s.Update(func(txn storeTxn) error {
+		for _, k := range []string{"a/2", "a/1", "b/1"} {
+			if err := txn.Set([]byte(k), []byte(k)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	keys := make([]string, 0)
+	err = s.View(func(txn storeTxn) error {
+		it := txn.Iterate([]byte("a/"), false)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			val, err := it.Value()
+			if err != nil {
+				return err
+			}
+			if string(val) != string(it.Key()) {
+				t.Fatalf("value of %s is not correct. Got: %s", it.Key(), val)
+			}
+			keys = append(keys, string(it.Key()))
+		}
+		it.Seek([]byte("a/15"))
+		if !it.Valid() || string(it.Key()) != "a/2" {
+			t.Fatalf("seek should move to the next greater key")
+		}
+		rev := txn.IterateReverse([]byte("a/"), true)
+		defer rev.Close()
+		rev.Rewind()
+		if !rev.Valid() || string(rev.Key()) != "a/2" {
+			t.Fatalf("reverse iterator should start at the last key of the prefix")
+		}
+		rev.Seek([]byte("a/15"))
+		if !rev.Valid() || string(rev.Key()) != "a/1" {
+			t.Fatalf("reverse seek should move to the next smaller key")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(keys) != 2 || keys[0] != "a/1" || keys[1] != "a/2" {
+		t.Fatalf("only the keys with the prefix should be iterated in order. Got: %v", keys)
+	}
+}
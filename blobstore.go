@@ -0,0 +1,225 @@
+package objst
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// PayloadBackend is the storage used for the payloads of a bucket.
+// The metadata is always persisted in badger.
+type PayloadBackend string
+
+const (
+	// PayloadBackendBadger stores the payloads in chunks in badger.
+	PayloadBackendBadger PayloadBackend = "badger"
+	// PayloadBackendFiles stores every payload as a file.
+	// It's suited for large payloads which would otherwise
+	// slow down the compaction of the badger store.
+	PayloadBackendFiles PayloadBackend = "files"
+)
+
+// blobStore persists the stored bytes of the payloads.
+type blobStore interface {
+	// Create returns a writer for the payload which
+	// is readable after the writer has been committed.
+	Create(blob string) (blobWriter, error)
+
+	// Open returns a reader for the payload or an
+	// error wrapping errKeyNotFound.
+	Open(blob string) (io.ReadCloser, error)
+
+	// Delete removes the payload. Deleting a
+	// missing payload is not an error.
+	Delete(blob string) error
+
+	Close() error
+}
+
+// blobWriter writes one payload.
+type blobWriter interface {
+	io.Writer
+
+	Commit() error
+
+	// Abort discards the written data. Data which has
+	// already been persisted will be removed by the
+	// write intent of the payload.
+	Abort()
+}
+
+// openBlobStore opens the payload store of
+// the bucket located in `dir` using the backend.
+func openBlobStore(dir string, backend PayloadBackend, opts BucketOptions) (blobStore, error) {
+	switch backend {
+	case PayloadBackendFiles:
+		f, err := openFileStore(filepath.Join(dir, blobsDir))
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	case PayloadBackendBadger, "":
+		s, err := openBadgerStore(opts.toBadgerOpts(filepath.Join(dir, dataDir), opts.Payload))
+		if err != nil {
+			return nil, err
+		}
+		return chunkStore{s}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownPayloadBackend, backend)
+}
+
+// chunkStore stores the payloads in chunks in a store.
+type chunkStore struct {
+	s store
+}
+
+func (c chunkStore) Create(blob string) (blobWriter, error) {
+	wb := c.s.NewBatch()
+	return &chunkBlobWriter{
+		chunkWriter: chunkWriter{wb: wb, id: blob},
+	}, nil
+}
+
+func (c chunkStore) Open(blob string) (io.ReadCloser, error) {
+	return newChunkReader(c.s, blob)
+}
+
+func (c chunkStore) Delete(blob string) error {
+	keys := make([][]byte, 0)
+	err := c.s.View(func(txn storeTxn) error {
+		it := txn.Iterate(chunkPrefix(blob), true)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			keys = append(keys, it.Key())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	wb := c.s.NewBatch()
+	defer wb.Cancel()
+	for _, key := range keys {
+		if err := wb.Delete(key); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+func (c chunkStore) Close() error {
+	return c.s.Close()
+}
+
+type chunkBlobWriter struct {
+	chunkWriter
+}
+
+func (w *chunkBlobWriter) Commit() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.wb.Flush()
+}
+
+func (w *chunkBlobWriter) Abort() {
+	w.wb.Cancel()
+}
+
+// fileStore stores every payload as a file. The files are
+// sharded into directories using the first characters of
+// the blob to keep the directories small.
+type fileStore struct {
+	dir string
+}
+
+// tmpDir is the directory of a fileStore
+// containing the payloads being written.
+const tmpDir = "tmp"
+
+func openFileStore(dir string) (*fileStore, error) {
+	// temporary files are left behind if the
+	// application crashed during a write.
+	if err := os.RemoveAll(filepath.Join(dir, tmpDir)); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, tmpDir), 0o755); err != nil {
+		return nil, err
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+func (f fileStore) path(blob string) string {
+	if len(blob) < 4 {
+		return filepath.Join(f.dir, blob)
+	}
+	return filepath.Join(f.dir, blob[:2], blob[2:4], blob)
+}
+
+func (f fileStore) Create(blob string) (blobWriter, error) {
+	file, err := os.CreateTemp(filepath.Join(f.dir, tmpDir), blob+"-*")
+	if err != nil {
+		return nil, err
+	}
+	return &fileBlobWriter{File: file, path: f.path(blob)}, nil
+}
+
+func (f fileStore) Open(blob string) (io.ReadCloser, error) {
+	return f.openFile(blob)
+}
+
+func (f fileStore) openFile(blob string) (*os.File, error) {
+	file, err := os.Open(f.path(blob))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %w", errKeyNotFound, err)
+	}
+	return file, err
+}
+
+func (f fileStore) Delete(blob string) error {
+	err := os.Remove(f.path(blob))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (f fileStore) Close() error {
+	return nil
+}
+
+// fileBlobWriter writes the payload into a temporary file
+// which is renamed to its final path on commit. A payload
+// file is therefore either complete or missing.
+type fileBlobWriter struct {
+	*os.File
+	path string
+}
+
+func (w *fileBlobWriter) Commit() error {
+	if err := w.Sync(); err != nil {
+		w.Abort()
+		return err
+	}
+	if err := w.File.Close(); err != nil {
+		os.Remove(w.Name())
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(w.path), 0o755); err != nil {
+		os.Remove(w.Name())
+		return err
+	}
+	if err := os.Rename(w.Name(), w.path); err != nil {
+		os.Remove(w.Name())
+		return err
+	}
+	return nil
+}
+
+func (w *fileBlobWriter) Abort() {
+	w.File.Close()
+	os.Remove(w.Name())
+}
@@ -0,0 +1,151 @@
+package objst
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func filesBackend(opts *BucketOptions) {
+	opts.PayloadBackend = PayloadBackendFiles
+}
+
+func TestFileBlobs(t *testing.T) {
+	b, dir := newTempBucket(t, filesBackend)
+	o, _ := NewObject(tEnv.name(), tEnv.owner())
+	pl := tEnv.payload(payloadChunkSize + 1)
+	o.Write(pl)
+	if err := b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	path := b.blobs.(*fileStore).path(blobOf(o.meta))
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("payload should be stored as a file. Got: %v", err)
+	}
+	if err := b.Shutdown(); err != nil {
+		t.Error(err)
+		return
+	}
+	// the backend of an existing bucket is kept
+	b = reopenTempBucket(t, dir, nil)
+	defer b.Shutdown()
+	got, err := b.GetPayload(o.ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(got, pl) {
+		t.Fatalf("payload of the object is not correct")
+	}
+	if err := b.DeleteByID(o.ID()); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("file of the payload should be removed. Got: %v", err)
+	}
+}
+
+func TestFileBlobsEncryption(t *testing.T) {
+	opts := testBucketOptions(filesBackend)
+	opts.EncryptionKey = tEnv.payload(32)
+	if _, err := OpenBucketAt(t.TempDir(), opts); !errors.Is(err, ErrUnencryptedBackend) {
+		t.Fatalf("encryption of the files backend should be rejected. Got: %v", err)
+	}
+}
+
+func TestFileBlobsRead(t *testing.T) {
+	b, _ := newTempBucket(t, filesBackend)
+	defer b.Shutdown()
+	o, _ := NewObject(tEnv.name(), tEnv.owner())
+	pl := tEnv.payload(payloadChunkSize + 1)
+	o.Write(pl)
+	if err := b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	buf := new(bytes.Buffer)
+	if err := b.Read(o.ID(), buf); err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(buf.Bytes(), pl) {
+		t.Fatalf("payload of the file is not correct")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.ReadContext(ctx, o.ID(), io.Discard); !errors.Is(err, context.Canceled) {
+		t.Fatalf("reading the file should be canceled. Got: %v", err)
+	}
+}
+
+func TestFileBlobsAbort(t *testing.T) {
+	b, dir := newTempBucket(t, filesBackend)
+	defer b.Shutdown()
+	w, err := b.blobs.Create("abcdef")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	w.Write(tEnv.payload(10))
+	w.Abort()
+	entries, err := os.ReadDir(filepath.Join(dir, blobsDir, tmpDir))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(entries) != 0 {
+		t.Fatalf("temporary file should be removed. Got: %d files", len(entries))
+	}
+	if _, err := b.blobs.Open("abcdef"); err == nil {
+		t.Fatalf("aborted payload should not be readable")
+	}
+}
+
+func TestHTTPReadFileRange(t *testing.T) {
+	b, _ := newTempBucket(t, filesBackend)
+	defer b.Shutdown()
+	ts := httptest.NewServer(NewHTTPHandler(b, DefaultHTTPHandlerOptions()))
+	defer ts.Close()
+	o := tEnv.obj()
+	if err := b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	target, err := url.JoinPath(ts.URL, route, "read", o.ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	req.Header.Set("Range", "bytes=2-5")
+	res, err := ts.Client().Do(req)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusPartialContent {
+		t.Fatalf("expected status code %d. Got: %d", http.StatusPartialContent, res.StatusCode)
+	}
+	got, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(got, o.Payload()[2:6]) {
+		t.Fatalf("range of the payload is not correct. Got: %s", got)
+	}
+}
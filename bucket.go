@@ -1,10 +1,13 @@
 package objst
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
+	"time"
 
 	"github.com/dgraph-io/badger/v4"
 	"github.com/google/uuid"
@@ -13,52 +16,134 @@ import (
 const (
 	basePath = "/var/lib/objst"
 	dataDir  = "data"
-	nameDir  = "name"
 	metaDir  = "meta"
+	blobsDir = "blobs"
 )
 
 type Bucket struct {
-	// store persists the objects and the
-	// actual data the client will interact with.
-	payload *badger.DB
-	name    *badger.DB
+	// blobs persists the payloads of the objects
+	// the client will interact with.
+	blobs blobStore
 
-	meta *badger.DB
+	// meta persists the metadata, the names, the
+	// indexes and the write intents of the objects.
+	meta store
 
+	indexes *indexSet
+
+	settings *settings
+
+	// keys provides the data keys for the envelope
+	// encryption of the payloads. If nil the payloads
+	// are not envelope encrypted.
+	keys KeyProvider
+
+	// maintenance runs the garbage
+	// collection in the background.
+	maintenance *maintenance
+
+	// BasePath is the directory of the bucket. It's
+	// empty if the bucket is kept in memory.
 	BasePath string
 }
 
-// NewBucket will create a new object storage with the provided options.
-// The `Dir` option will be overwritten by the application to have
-// a gurantee about the data path.
+// NewBucket will create a new object storage with the provided
+// options located in a new directory of the default base path.
 func NewBucket(opts BucketOptions) (*Bucket, error) {
-	uniqueBasePath := filepath.Join(basePath, uuid.NewString())
-	payloadDataDir := filepath.Join(uniqueBasePath, dataDir)
-	opts.overwriteDataDir(payloadDataDir)
-	payload, err := badger.Open(opts.toBadgerOpts())
+	return OpenBucketAt(filepath.Join(basePath, uuid.NewString()), opts)
+}
+
+// OpenBucket opens the bucket with the given name located
+// in the default base path. If no bucket with the name
+// exists a new one will be created.
+func OpenBucket(name string, opts BucketOptions) (*Bucket, error) {
+	if !isValidBucketName(name) {
+		return nil, ErrInvalidBucketName
+	}
+	return OpenBucketAt(filepath.Join(basePath, name), opts)
+}
+
+// OpenBucketAt opens the bucket located in the directory `dir`.
+// If the directory doesn't exist a new bucket will be created.
+// An existing directory has to be empty or contain a valid
+// bucket layout. An existing bucket keeps its payload backend
+// regardless of the options.
+func OpenBucketAt(dir string, opts BucketOptions) (*Bucket, error) {
+	if err := prepareLayout(dir); err != nil {
+		return nil, err
+	}
+	backend := payloadBackendOf(dir, opts.PayloadBackend)
+	if backend == PayloadBackendFiles && len(opts.EncryptionKey) > 0 {
+		// the files are written as they are and
+		// would silently be stored unencrypted.
+		return nil, fmt.Errorf("%w: %s", ErrUnencryptedBackend, backend)
+	}
+	blobs, err := openBlobStore(dir, backend, opts)
 	if err != nil {
 		return nil, err
 	}
-	nameDataDir := filepath.Join(uniqueBasePath, nameDir)
-	name, err := badger.Open(badger.DefaultOptions(nameDataDir))
+	metaOpts := opts.toBadgerOpts(filepath.Join(dir, metaDir), opts.Meta)
+	b, err := openBucket(blobs, metaOpts, opts)
 	if err != nil {
 		return nil, err
 	}
-	metaDataDir := filepath.Join(uniqueBasePath, metaDir)
-	meta, err := badger.Open(badger.DefaultOptions(metaDataDir))
+	b.BasePath = dir
+	return b, nil
+}
+
+// NewMemoryBucket creates a new bucket keeping all stores in
+// memory. Nothing is written to the filesystem and all objects
+// are lost when the bucket is shut down. It's intended for
+// tests and ephemeral caches. The payload backend option is
+// ignored because the payloads are always kept in memory.
+func NewMemoryBucket(opts BucketOptions) (*Bucket, error) {
+	payload, err := openBadgerStore(opts.toMemoryBadgerOpts(opts.Payload))
 	if err != nil {
 		return nil, err
 	}
+	return openBucket(chunkStore{payload}, opts.toMemoryBadgerOpts(opts.Meta), opts)
+}
+
+// openBucket opens the meta store of a bucket
+// and recovers the state of the bucket.
+func openBucket(blobs blobStore, metaOpts badger.Options, opts BucketOptions) (*Bucket, error) {
+	meta, err := openBadgerStore(metaOpts)
+	if err != nil {
+		blobs.Close()
+		return nil, err
+	}
 	b := &Bucket{
-		payload:  payload,
-		name:     name,
-		meta:     meta,
-		BasePath: uniqueBasePath,
+		blobs:       blobs,
+		meta:        meta,
+		indexes:     newIndexSet(),
+		settings:    &settings{},
+		keys:        opts.KeyProvider,
+		maintenance: newMaintenance(opts),
+	}
+	if err := b.loadSettings(); err != nil {
+		b.Shutdown()
+		return nil, err
+	}
+	if err := b.loadIndexes(); err != nil {
+		b.Shutdown()
+		return nil, err
 	}
+	if err := b.replayIntents(); err != nil {
+		b.Shutdown()
+		return nil, err
+	}
+	b.maintenance.start("garbage collection", opts.GCInterval, b.collectGarbage)
+	b.maintenance.start("lifecycle", opts.LifecycleInterval, b.expire)
 	return b, nil
 }
 
 func (b Bucket) Execute(q *Query) ([]*Object, error) {
+	return b.ExecuteContext(context.Background(), q)
+}
+
+// ExecuteContext executes the query like Execute and
+// stops with the error of ctx once ctx is done.
+func (b Bucket) ExecuteContext(ctx context.Context, q *Query) ([]*Object, error) {
 	if err := q.isValid(); err != nil {
 		return nil, err
 	}
@@ -66,9 +151,9 @@ func (b Bucket) Execute(q *Query) ([]*Object, error) {
 	// do not return any objects
 	var defaultRes []*Object
 	if q.op == OperationGet {
-		return b.Get(q)
+		return b.GetContext(ctx, q)
 	}
-	return defaultRes, b.Delete(q)
+	return defaultRes, b.DeleteContext(ctx, q)
 }
 
 func (b Bucket) GetByID(id string) (*Object, error) {
@@ -83,111 +168,292 @@ func (b Bucket) GetByName(name, owner string) (*Object, error) {
 	return b.GetByID(id)
 }
 
+// Get returns the objects matching the query. If the query
+// has a limit only the first page of objects is returned.
+// Use `GetPage` to fetch the following pages.
 func (b Bucket) Get(q *Query) ([]*Object, error) {
-	ids, err := b.getMatchingIDs(q)
+	return b.GetContext(context.Background(), q)
+}
+
+// GetContext returns the objects matching the query like Get.
+// Scanning the objects and loading their payloads stops with
+// the error of ctx once ctx is done.
+func (b Bucket) GetContext(ctx context.Context, q *Query) ([]*Object, error) {
+	page, err := b.GetPageContext(ctx, q)
 	if err != nil {
 		return nil, err
 	}
-	return b.idsToObjs(ids)
+	return page.Items, nil
+}
+
+// GetPage returns the page of objects matching the query
+// and the cursor to fetch the next page. Only the payloads
+// of the objects included in the page will be loaded.
+func (b Bucket) GetPage(q *Query) (*Page[*Object], error) {
+	return b.GetPageContext(context.Background(), q)
+}
+
+// GetPageContext returns the page of objects matching the query
+// like GetPage and stops with the error of ctx once ctx is done.
+func (b Bucket) GetPageContext(ctx context.Context, q *Query) (*Page[*Object], error) {
+	if err := q.isValid(); err != nil {
+		return nil, err
+	}
+	metas, next, err := b.findPage(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := b.metasToObjs(ctx, metas)
+	if err != nil {
+		return nil, err
+	}
+	return &Page[*Object]{Items: objs, Cursor: next}, nil
+}
+
+// Describe returns the descriptor of the object
+// without loading the payload of the object.
+func (b Bucket) Describe(id string) (*Descriptor, error) {
+	meta, err := b.GetMeta(id)
+	if err != nil {
+		return nil, err
+	}
+	return &Descriptor{meta: meta}, nil
+}
+
+// List returns the page of descriptors of the objects matching
+// the query. In contrast to `GetPage` no payload is loaded which
+// makes listing a large number of objects cheap.
+func (b Bucket) List(q *Query) (*Page[*Descriptor], error) {
+	return b.ListContext(context.Background(), q)
+}
+
+// ListContext returns the page of descriptors like List
+// and stops with the error of ctx once ctx is done.
+func (b Bucket) ListContext(ctx context.Context, q *Query) (*Page[*Descriptor], error) {
+	if err := q.isValid(); err != nil {
+		return nil, err
+	}
+	metas, next, err := b.findPage(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	ds := make([]*Descriptor, 0, len(metas))
+	for _, meta := range metas {
+		ds = append(ds, &Descriptor{meta: meta})
+	}
+	return &Page[*Descriptor]{Items: ds, Cursor: next}, nil
 }
 
 // Create inserts the given object into the storage.
+// The object is either inserted completely or not at all.
 // If you have to create multiple objects use
 // `BatchCreate` which is more performant than
 // multiple calls to Create.
 func (b Bucket) Create(obj *Object) error {
-	e, err := b.createObjectEntry(obj)
+	return b.CreateContext(context.Background(), obj)
+}
+
+// CreateContext inserts the object like Create. If ctx is done
+// before the object is committed, nothing is inserted and the
+// error of ctx is returned.
+func (b Bucket) CreateContext(ctx context.Context, obj *Object) error {
+	if err := obj.isValid(); err != nil {
+		return err
+	}
+	return b.CreateFromContext(ctx, obj, bytes.NewReader(obj.Payload()))
+}
+
+// CreateFrom inserts the given object into the storage
+// using r as the payload of the object. The payload is
+// streamed in chunks into the storage which allows to
+// insert large payloads without holding them in memory.
+// The payload written to the object itself is ignored.
+func (b Bucket) CreateFrom(obj *Object, r io.Reader) error {
+	return b.CreateFromContext(context.Background(), obj, r)
+}
+
+// CreateFromContext inserts the object using r as the payload like
+// CreateFrom. Streaming the payload stops once ctx is done and
+// nothing is inserted if ctx is done before the object is committed.
+func (b Bucket) CreateFromContext(ctx context.Context, obj *Object, r io.Reader) error {
+	if err := b.isCreatable(obj); err != nil {
+		return err
+	}
+	f, err := b.formatFor(obj.meta)
 	if err != nil {
 		return err
 	}
-	if err := b.insertPayload(string(e.Key), e.Value); err != nil {
+	// every upload is written to a new payload
+	blob := uuid.NewString()
+	if err := b.insertIntents(blob); err != nil {
 		return err
 	}
-	if err := b.insertName(obj.Name(), obj.Owner(), obj.ID()); err != nil {
+	stat, err := b.insertPayload(blob, newCtxReader(ctx, r), f)
+	if err != nil {
+		return b.rollback(err, blob)
+	}
+	if stat.size == 0 {
+		return b.rollback(ErrEmptyPayload, blob)
+	}
+	if err := ctx.Err(); err != nil {
+		return b.rollback(err, blob)
+	}
+	meta := obj.meta.clone()
+	meta.set(metaKeyBlob, blob)
+	stat.stamp(meta)
+	var orphans []string
+	err = b.meta.Update(func(txn storeTxn) error {
+		if err := b.dedupe(txn, meta); err != nil {
+			return err
+		}
+		blobs, err := b.commitObject(txn, meta)
+		orphans = blobs
 		return err
+	})
+	if err != nil {
+		return b.rollback(err, blob)
 	}
-	if err := b.insertMeta(obj.ID(), obj.meta); err != nil {
+	if err := b.resolveIntents(append(duplicateOf(blob, meta), orphans...)...); err != nil {
 		return err
 	}
+	obj.meta = meta
 	obj.markAsImmutable()
 	return nil
 }
 
 // BatchCreate inserts multiple objects in an efficient way.
+// Every object is either inserted completely or not at all
+// but the batch itself might be inserted partially.
 func (b Bucket) BatchCreate(objs []*Object) error {
-	wb := b.payload.NewWriteBatch()
-	defer wb.Cancel()
+	blobs := make([]string, 0, len(objs))
+	names := make(map[string]bool, len(objs))
 	for _, obj := range objs {
-		e, err := b.createObjectEntry(obj)
-		if err != nil {
+		if err := obj.isValid(); err != nil {
 			return err
 		}
-		if err := wb.SetEntry(e); err != nil {
+		if err := b.isCreatable(obj); err != nil {
 			return err
 		}
-		if err := b.insertName(obj.Name(), obj.Owner(), obj.ID()); err != nil {
-			return err
+		name := string(nameKey(obj.Name(), obj.Owner()))
+		if names[name] {
+			return nameConflict(obj.Name(), obj.Owner())
 		}
-		if err := b.insertMeta(obj.ID(), obj.meta); err != nil {
+		names[name] = true
+		blobs = append(blobs, uuid.NewString())
+	}
+	if err := b.insertIntents(blobs...); err != nil {
+		return err
+	}
+	metas := make([]*Metadata, 0, len(objs))
+	for i, obj := range objs {
+		f, err := b.formatFor(obj.meta)
+		if err != nil {
+			return b.rollback(err, blobs...)
+		}
+		stat, err := b.insertPayload(blobs[i], bytes.NewReader(obj.Payload()), f)
+		if err != nil {
+			return b.rollback(err, blobs...)
+		}
+		meta := obj.meta.clone()
+		meta.set(metaKeyBlob, blobs[i])
+		stat.stamp(meta)
+		metas = append(metas, meta)
+	}
+	for i := 0; i < len(objs); {
+		n, orphans, err := b.commitBatch(metas[i:])
+		if err != nil {
+			return b.rollback(err, blobs[i:]...)
+		}
+		if err := b.resolveIntents(orphans...); err != nil {
 			return err
 		}
-		obj.markAsImmutable()
+		for j := i; j < i+n; j++ {
+			if err := b.resolveIntents(duplicateOf(blobs[j], metas[j])...); err != nil {
+				return err
+			}
+			objs[j].meta = metas[j]
+			objs[j].markAsImmutable()
+		}
+		i += n
 	}
-	return wb.Flush()
+	return nil
 }
 
+// Delete removes the objects matching the query. If the
+// query has a limit only the first page will be removed.
 func (b Bucket) Delete(q *Query) error {
-	ids, err := b.getMatchingIDs(q)
+	return b.DeleteContext(context.Background(), q)
+}
+
+// DeleteContext removes the objects matching the query like
+// Delete. Once ctx is done no further objects are removed and
+// the error of ctx is returned. Every object is either removed
+// completely or not at all.
+func (b Bucket) DeleteContext(ctx context.Context, q *Query) error {
+	if err := q.isValid(); err != nil {
+		return err
+	}
+	metas, _, err := b.findPage(ctx, q)
 	if err != nil {
-		return nil
+		return err
 	}
-	for _, id := range ids {
-		if err := b.DeleteByID(id); err != nil {
+	for _, meta := range metas {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := b.deleteObject(meta.Get(MetaKeyID))
+		if errors.Is(err, ErrObjectNotFound) {
+			// removed concurrently
+			continue
+		}
+		if err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// GetPayload returns the whole payload of the object.
+// Use `Read` or `OpenPayload` for large payloads.
 func (b Bucket) GetPayload(id string) ([]byte, error) {
-	var payload []byte
-	err := b.payload.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(id))
-		if err != nil {
-			return err
-		}
-		dst := make([]byte, item.ValueSize())
-		if _, err := item.ValueCopy(dst); err != nil {
-			return err
-		}
-		payload = dst
-		return nil
-	})
-	return payload, err
+	r, err := b.OpenPayload(id)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// OpenPayload returns a reader which streams the payload of
+// the object chunk by chunk. The integrity of the payload is
+// verified after the last chunk has been read and reported
+// as ErrChecksumMismatch instead of io.EOF.
+func (b Bucket) OpenPayload(id string) (io.ReadCloser, error) {
+	meta, err := b.GetMeta(id)
+	if err != nil {
+		return nil, err
+	}
+	return b.openPayload(meta)
 }
 
 func (b Bucket) GetMeta(id string) (*Metadata, error) {
-	meta := NewMetadata()
-	err := b.meta.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(id))
+	var meta *Metadata
+	err := b.meta.View(func(txn storeTxn) error {
+		m, err := b.getMeta(txn, id)
 		if err != nil {
 			return err
 		}
-		dst := make([]byte, item.ValueSize())
-		if _, err := item.ValueCopy(dst); err != nil {
-			return err
+		if isExpired(m, time.Now()) {
+			return fmt.Errorf("%w: %s: expired", ErrObjectNotFound, id)
 		}
-		return meta.Unmarshal(dst)
+		meta = m
+		return nil
 	})
 	return meta, err
 }
 
 func (b Bucket) DeleteByID(id string) error {
-	meta, err := b.GetMeta(id)
-	if err != nil {
-		return err
-	}
-	return b.deleteObject(meta)
+	return b.deleteObject(id)
 }
 
 func (b Bucket) DeleteByName(name, owner string) error {
@@ -198,60 +464,53 @@ func (b Bucket) DeleteByName(name, owner string) error {
 	return b.DeleteByID(id)
 }
 
+// Read streams the payload of the object to w
+// without loading the whole payload into memory.
+// Plain payload files are copied directly which
+// allows the use of sendfile if w supports it.
 func (b Bucket) Read(id string, w io.Writer) error {
-	obj, err := b.GetByID(id)
+	return b.ReadContext(context.Background(), id, w)
+}
+
+// ReadContext streams the payload of the object to w like
+// Read and stops with the error of ctx once ctx is done.
+func (b Bucket) ReadContext(ctx context.Context, id string, w io.Writer) error {
+	meta, err := b.GetMeta(id)
+	if err != nil {
+		return err
+	}
+	f, isFile, err := b.openFile(meta)
 	if err != nil {
 		return err
 	}
-	_, err = io.Copy(w, obj)
+	if isFile {
+		defer f.Close()
+		return copyFile(ctx, w, f)
+	}
+	r, err := b.openPayload(meta)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(w, newCtxReader(ctx, r))
 	return err
 }
 
 func (b Bucket) Shutdown() error {
-	if err := b.payload.Close(); err != nil {
-		return err
-	}
-	if err := b.meta.Close(); err != nil {
+	b.maintenance.shutdown()
+	if err := b.blobs.Close(); err != nil {
 		return err
 	}
-	return b.name.Close()
-}
-
-func (b Bucket) getMatchingIDs(q *Query) ([]string, error) {
-	const prefetchSize = 10
-	ids := make([]string, 0, prefetchSize)
-	err := b.meta.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchSize = prefetchSize
-		it := txn.NewIterator(opts)
-		defer it.Close()
-
-		for it.Rewind(); it.Valid(); it.Next() {
-			err := it.Item().Value(func(val []byte) error {
-				meta := NewMetadata()
-				if err := meta.Unmarshal(val); err != nil {
-					return err
-				}
-				if meta.Compare(q.params, q.act) {
-					dst := make([]byte, it.Item().KeySize())
-					it.Item().KeyCopy(dst)
-					ids = append(ids, string(dst))
-				}
-				return nil
-			})
-			if err != nil {
-				return err
-			}
-		}
-		return nil
-	})
-	return ids, err
+	return b.meta.Close()
 }
 
-func (b Bucket) idsToObjs(ids []string) ([]*Object, error) {
-	objs := make([]*Object, 0, len(ids))
-	for _, id := range ids {
-		obj, err := b.composeObjectByID(id)
+func (b Bucket) metasToObjs(ctx context.Context, metas []*Metadata) ([]*Object, error) {
+	objs := make([]*Object, 0, len(metas))
+	for _, meta := range metas {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		obj, err := b.composeObject(meta)
 		if err != nil {
 			return nil, err
 		}
@@ -260,83 +519,143 @@ func (b Bucket) idsToObjs(ids []string) ([]*Object, error) {
 	return objs, nil
 }
 
+// isNameExisting reports if the name is held by an object
+// of the owner which hasn't expired.
 func (b Bucket) isNameExisting(name, owner string) bool {
-	err := b.name.View(func(txn *badger.Txn) error {
-		_, err := txn.Get([]byte(b.nameFormat(name, owner)))
-		return err
-	})
-	return !errors.Is(err, badger.ErrKeyNotFound)
+	id, err := b.getIDByName(name, owner)
+	if err == nil {
+		_, err = b.GetMeta(id)
+	}
+	return !errors.Is(err, ErrObjectNotFound)
 }
 
-func (b Bucket) insertName(name, owner, id string) error {
-	return b.name.Update(func(txn *badger.Txn) error {
-		return txn.Set([]byte(b.nameFormat(name, owner)), []byte(id))
-	})
+func (b Bucket) getMeta(txn storeTxn, id string) (*Metadata, error) {
+	meta := NewMetadata()
+	val, err := txn.Get(metaKey(id))
+	if errors.Is(err, errKeyNotFound) {
+		return nil, fmt.Errorf("%w: %s: %w", ErrObjectNotFound, id, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return meta, meta.Unmarshal(val)
 }
 
-func (b Bucket) insertMeta(id string, meta *Metadata) error {
-	return b.meta.Update(func(txn *badger.Txn) error {
-		data, err := meta.Marshal()
-		if err != nil {
-			return err
+// isCreatable validates the metadata of the object and checks if
+// the name of the object is still available. The name of an
+// existing object is available if the bucket is versioned.
+// Objects of owners which reached their quota are rejected
+// before the payload is written.
+func (b Bucket) isCreatable(obj *Object) error {
+	if err := obj.isValidMeta(); err != nil {
+		return err
+	}
+	// every payload contains at least one byte
+	delta := Usage{Objects: 1, Bytes: 1}
+	if b.isNameExisting(obj.Name(), obj.Owner()) {
+		if !b.IsVersioned() {
+			return nameConflict(obj.Name(), obj.Owner())
 		}
-		return txn.Set([]byte(id), data)
-	})
-}
-
-func (b Bucket) insertPayload(id string, pl []byte) error {
-	return b.payload.Update(func(txn *badger.Txn) error {
-		return txn.Set([]byte(id), pl)
-	})
-}
-
-func (b Bucket) deleteName(name, owner string) error {
-	return b.name.Update(func(txn *badger.Txn) error {
-		return txn.Delete([]byte(b.nameFormat(name, owner)))
-	})
-}
-
-func (b Bucket) deleteMeta(id string) error {
-	return b.meta.Update(func(txn *badger.Txn) error {
-		return txn.Delete([]byte(id))
-	})
-}
-
-func (b Bucket) deletePayload(id string) error {
-	return b.payload.Update(func(txn *badger.Txn) error {
-		return txn.Delete([]byte(id))
-	})
-}
-
-func (b Bucket) nameFormat(name, owner string) string {
-	// choosing the name format as <name>_<owner> allows
-	// to have unique names in the context of a owner e.g.
-	// owner 1 can have foo_1 and owner 2 can have foo_2
-	// without having a duplication error.
-	return fmt.Sprintf("%s_%s", name, owner)
+		delta.Objects = 0
+	}
+	return b.checkQuota(obj.Owner(), delta)
 }
 
-// createObjectEntry validates the object and creates a entry.
-func (b Bucket) createObjectEntry(obj *Object) (*badger.Entry, error) {
-	if err := obj.isValid(); err != nil {
+// commitObject inserts the name and metadata of the object and removes
+// the write intent of its payload. The name is checked again to detect
+// concurrent inserts of the same name. If the name exists and the bucket
+// is versioned the metadata is committed as a new version of the existing
+// object and the id of meta is replaced with the id of the existing object.
+// An expired object holding the name is removed first and its unreferenced
+// payloads are returned to be resolved after the commit. The object is
+// charged to the usage of its owner.
+func (b Bucket) commitObject(txn storeTxn, meta *Metadata) ([]string, error) {
+	name, owner := meta.Get(MetaKeyName), meta.Get(MetaKeyOwner)
+	orphans, err := b.reclaimName(txn, name, owner)
+	if err != nil {
 		return nil, err
 	}
-	if b.isNameExisting(obj.Name(), obj.Owner()) {
-		return nil, fmt.Errorf("object with the name %s for the owner %s exists", obj.Name(), obj.Owner())
+	delta := Usage{Bytes: sizeOf(meta)}
+	id, err := b.getIDByNameTxn(txn, name, owner)
+	if err == nil && !b.IsVersioned() {
+		return nil, nameConflict(name, owner)
+	}
+	if err == nil {
+		if err := b.archiveVersion(txn, id, meta); err != nil {
+			return nil, err
+		}
+	}
+	if errors.Is(err, errKeyNotFound) {
+		delta.Objects = 1
+		meta.set(MetaKeyVersion, "1")
+		err = txn.Set(nameKey(name, owner), []byte(meta.Get(MetaKeyID)))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := b.charge(txn, owner, delta); err != nil {
+		return nil, err
 	}
-	data, err := obj.Marshal()
+	data, err := meta.Marshal()
 	if err != nil {
 		return nil, err
 	}
-	e := badger.NewEntry([]byte(obj.ID()), data)
-	return e, nil
+	if err := txn.Set(metaKey(meta.Get(MetaKeyID)), data); err != nil {
+		return nil, err
+	}
+	if err := b.setIndexes(txn, meta); err != nil {
+		return nil, err
+	}
+	return orphans, txn.Delete(intentKey(blobOf(meta)))
+}
+
+// commitBatch commits as many objects as fit into one
+// transaction and returns the number of committed objects
+// and the payloads of the removed expired objects. The
+// committed metadata replaces the given metadata.
+func (b Bucket) commitBatch(metas []*Metadata) (int, []string, error) {
+	txn := b.meta.NewTxn(true)
+	defer txn.Discard()
+	committed := make([]*Metadata, 0, len(metas))
+	orphans := make([]string, 0)
+	for i, meta := range metas {
+		// the metadata might be modified e.g. if
+		// a new version gets committed.
+		meta = meta.clone()
+		err := b.dedupe(txn, meta)
+		if err == nil {
+			var blobs []string
+			blobs, err = b.commitObject(txn, meta)
+			orphans = append(orphans, blobs...)
+		}
+		if errors.Is(err, errTxnTooBig) && i > 0 {
+			// the transaction might contain parts of the object
+			// which didn't fit. Retry without the object.
+			txn.Discard()
+			return b.commitBatch(metas[:i])
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		committed = append(committed, meta)
+	}
+	if err := txn.Commit(); err != nil {
+		return 0, nil, err
+	}
+	copy(metas, committed)
+	return len(metas), orphans, nil
 }
 
 func (b Bucket) composeObject(meta *Metadata) (*Object, error) {
 	obj := &Object{
 		meta: meta,
 	}
-	pl, err := b.GetPayload(meta.Get(MetaKeyID))
+	r, err := b.openPayload(meta)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	pl, err := io.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
@@ -354,32 +673,91 @@ func (b Bucket) composeObjectByID(id string) (*Object, error) {
 
 func (b Bucket) getIDByName(name, owner string) (string, error) {
 	var id string
-	err := b.name.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(b.nameFormat(name, owner)))
+	err := b.meta.View(func(txn storeTxn) error {
+		res, err := b.getIDByNameTxn(txn, name, owner)
+		id = res
+		return err
+	})
+	return id, err
+}
+
+func (b Bucket) getIDByNameTxn(txn storeTxn, name, owner string) (string, error) {
+	id, err := txn.Get(nameKey(name, owner))
+	if errors.Is(err, errKeyNotFound) {
+		return "", fmt.Errorf("%w: %s of the owner %s: %w", ErrObjectNotFound, name, owner, err)
+	}
+	return string(id), err
+}
+
+// deleteObject will delete all parts of an object including
+// metadata, name, versions, usage and unshared payloads. The name,
+// metadata and versions are removed in one transaction leaving
+// write intents for the payloads which will be removed afterwards.
+// The metadata is read in the same transaction because removing
+// an outdated state would release payloads of other objects.
+func (b Bucket) deleteObject(id string) error {
+	var blobs []string
+	err := b.meta.Update(func(txn storeTxn) error {
+		meta, err := b.getMeta(txn, id)
 		if err != nil {
 			return err
 		}
-		dst := make([]byte, item.ValueSize())
-		if _, err := item.ValueCopy(dst); err != nil {
-			return err
-		}
-		id = string(dst)
-		return nil
+		blobs, err = b.removeObject(txn, meta)
+		return err
 	})
-	return id, err
+	if err != nil {
+		return err
+	}
+	return b.resolveIntents(blobs...)
 }
 
-// deleteObject will delete all parts of an object
-// including metadata, name and payload entry.
-func (b Bucket) deleteObject(meta *Metadata) error {
-	id := meta.Get(MetaKeyID)
-	name := meta.Get(MetaKeyName)
-	owner := meta.Get(MetaKeyOwner)
-	if err := b.deleteName(name, owner); err != nil {
-		return err
+// removeObject removes all parts of the object in txn and returns
+// the unreferenced payloads which have to be resolved after the
+// transaction has been committed.
+func (b Bucket) removeObject(txn storeTxn, meta *Metadata) ([]string, error) {
+	id, owner := meta.Get(MetaKeyID), meta.Get(MetaKeyOwner)
+	if err := txn.Delete(nameKey(meta.Get(MetaKeyName), owner)); err != nil {
+		return nil, err
 	}
-	if err := b.deletePayload(id); err != nil {
-		return err
+	if err := txn.Delete(metaKey(id)); err != nil {
+		return nil, err
+	}
+	if err := b.deleteIndexes(txn, meta); err != nil {
+		return nil, err
+	}
+	versions, err := b.deleteVersions(txn, id)
+	if err != nil {
+		return nil, err
+	}
+	u := Usage{Objects: 1}
+	for _, v := range append(versions, meta) {
+		u.Bytes += sizeOf(v)
+	}
+	if err := refund(txn, owner, u); err != nil {
+		return nil, err
+	}
+	return releaseBlobs(txn, append(versions, meta))
+}
+
+// reclaimName removes the object holding the name of the owner
+// in txn if the object has expired. Expired objects are hidden
+// and must not block their name until the lifecycle removes
+// them. The returned payloads have to be resolved after the
+// transaction has been committed.
+func (b Bucket) reclaimName(txn storeTxn, name, owner string) ([]string, error) {
+	id, err := b.getIDByNameTxn(txn, name, owner)
+	if errors.Is(err, errKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	meta, err := b.getMeta(txn, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isExpired(meta, time.Now()) {
+		return nil, nil
 	}
-	return b.deleteMeta(id)
+	return b.removeObject(txn, meta)
 }
@@ -2,10 +2,12 @@ package objst
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
-
-	"github.com/dgraph-io/badger/v4"
 )
 
 func TestCreate(t *testing.T) {
@@ -43,7 +45,7 @@ func TestDeleteByID(t *testing.T) {
 		t.Error(err)
 	}
 	_, err := tEnv.b.GetByID(o.ID())
-	if !errors.Is(err, badger.ErrKeyNotFound) {
+	if !errors.Is(err, ErrObjectNotFound) {
 		t.Fatalf("Key should be not found.")
 	}
 }
@@ -59,7 +61,7 @@ func TestDeleteByName(t *testing.T) {
 		return
 	}
 	_, err := tEnv.b.GetByID(o.ID())
-	if !errors.Is(err, badger.ErrKeyNotFound) {
+	if !errors.Is(err, ErrObjectNotFound) {
 		t.Fatalf("Key should be not found.")
 	}
 }
@@ -89,10 +91,36 @@ func TestNameDuplication(t *testing.T) {
 	o1.meta.set(MetaKeyOwner, o2.Owner())
 	objs := make([]*Object, 0, 2)
 	objs = append(objs, o1, o2)
-	if err := tEnv.b.BatchCreate(objs); err != nil {
-		return
+	if err := tEnv.b.BatchCreate(objs); !errors.Is(err, ErrNameConflict) {
+		t.Fatalf("should not create objects with the same name. Got: %v", err)
+	}
+}
+
+func TestDeleteInvalidQuery(t *testing.T) {
+	q := NewQuery().Owner(tEnv.owner()).Cursor("invalid")
+	if err := tEnv.b.Delete(q); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("error of the query should be returned. Got: %v", err)
+	}
+}
+
+func TestDeleteEmptyQuery(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	objs := tEnv.nObj(3)
+	if err := b.BatchCreate(objs); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Delete(NewQuery()); !errors.Is(err, ErrEmptyQuery) {
+		t.Fatalf("empty query should be rejected. Got: %v", err)
+	}
+	if err := b.Delete(NewQuery().Owner("not-a-uuid")); !errors.Is(err, ErrInvalidUUID) {
+		t.Fatalf("invalid owner should be rejected. Got: %v", err)
+	}
+	for _, obj := range objs {
+		if _, err := b.GetByID(obj.ID()); err != nil {
+			t.Fatalf("object should not be deleted. Got: %v", err)
+		}
 	}
-	t.Fatal("should not create objects with the same name.")
 }
 
 func TestGetByName(t *testing.T) {
@@ -312,3 +340,179 @@ func BenchmarkBatchCreate(b *testing.B) {
 	}
 	b.ReportAllocs()
 }
+
+func TestOpenBucketAtReopen(t *testing.T) {
+	b, dir := newTempBucket(t, nil)
+	o := tEnv.obj()
+	if err := b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := b.Shutdown(); err != nil {
+		t.Error(err)
+		return
+	}
+	b = reopenTempBucket(t, dir, nil)
+	defer b.Shutdown()
+	oG, err := b.GetByName(o.Name(), o.Owner())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(oG.Payload(), o.Payload()) {
+		t.Fatalf("payload is not the same after reopen. Got: %s. Expected: %s", oG.Payload(), o.Payload())
+	}
+}
+
+func TestOpenBucketAtInvalidLayout(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "foreign.txt"), tEnv.payload(10), 0o644); err != nil {
+		t.Error(err)
+		return
+	}
+	_, err := OpenBucketAt(dir, testBucketOptions(nil))
+	if !errors.Is(err, ErrInvalidBucketLayout) {
+		t.Fatalf("opening a foreign directory should fail. Got: %v", err)
+	}
+}
+
+func TestOpenBucketInvalidName(t *testing.T) {
+	_, err := OpenBucket("../escape", NewDefaultBucketOptions())
+	if !errors.Is(err, ErrInvalidBucketName) {
+		t.Fatalf("bucket name should be invalid. Got: %v", err)
+	}
+}
+
+func TestMemoryBucket(t *testing.T) {
+	b, err := NewMemoryBucket(testBucketOptions(nil))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer b.Shutdown()
+	if b.BasePath != "" {
+		t.Fatalf("memory bucket should not have a base path. Got: %s", b.BasePath)
+	}
+	o, _ := NewObject(tEnv.name(), tEnv.owner())
+	pl := tEnv.payload(payloadChunkSize*2 + 1)
+	o.Write(pl)
+	if err := b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	got, err := b.GetPayload(o.ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(got, pl) {
+		t.Fatalf("payload of the object is not correct")
+	}
+}
+
+func TestContextCanceled(t *testing.T) {
+	o := tEnv.obj()
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	q := NewQuery().Owner(o.Owner())
+	tests := []struct {
+		name string
+		fn   func() error
+	}{
+		{
+			name: "create",
+			fn:   func() error { return tEnv.b.CreateContext(ctx, tEnv.obj()) },
+		},
+		{
+			name: "get",
+			fn: func() error {
+				_, err := tEnv.b.GetContext(ctx, q)
+				return err
+			},
+		},
+		{
+			name: "execute",
+			fn: func() error {
+				_, err := tEnv.b.ExecuteContext(ctx, NewQuery().Owner(o.Owner()).Operation(OperationDelete))
+				return err
+			},
+		},
+		{
+			name: "delete",
+			fn:   func() error { return tEnv.b.DeleteContext(ctx, q) },
+		},
+		{
+			name: "read",
+			fn:   func() error { return tEnv.b.ReadContext(ctx, o.ID(), io.Discard) },
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.fn(); !errors.Is(err, context.Canceled) {
+				t.Fatalf("operation should be canceled. Got: %v", err)
+			}
+		})
+	}
+	if _, err := tEnv.b.GetByID(o.ID()); err != nil {
+		t.Fatalf("object should not be deleted. Got: %v", err)
+	}
+}
+
+// cancelReader cancels the context after the first read.
+type cancelReader struct {
+	r      io.Reader
+	cancel context.CancelFunc
+}
+
+func (c cancelReader) Read(p []byte) (int, error) {
+	defer c.cancel()
+	return c.r.Read(p[:1])
+}
+
+func TestCreateFromContextCanceledWhileStreaming(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	o := tEnv.emptyObj()
+	r := cancelReader{r: bytes.NewReader(tEnv.payload(10)), cancel: cancel}
+	if err := tEnv.b.CreateFromContext(ctx, o, r); !errors.Is(err, context.Canceled) {
+		t.Fatalf("create should be canceled. Got: %v", err)
+	}
+	if _, err := tEnv.b.GetByName(o.Name(), o.Owner()); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("canceled object should not be created. Got: %v", err)
+	}
+}
+
+func TestDeleteTwiceAfterCopy(t *testing.T) {
+	o := tEnv.obj()
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	d, err := tEnv.b.Copy(o.ID(), tEnv.name(), o.Owner(), nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := tEnv.b.DeleteByID(o.ID()); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := tEnv.b.DeleteByID(o.ID()); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("second delete should not find the object. Got: %v", err)
+	}
+	if _, err := tEnv.b.GetPayload(d.ID()); err != nil {
+		t.Fatalf("payload of the copy should be kept. Got: %v", err)
+	}
+	u, err := tEnv.b.Usage(o.Owner())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if u != (Usage{Objects: 1, Bytes: 10}) {
+		t.Fatalf("usage should only contain the copy. Got: %+v", u)
+	}
+}
@@ -1,18 +1,161 @@
 package objst
 
-import "github.com/dgraph-io/badger/v4"
+import (
+	"time"
 
-type BucketOptions badger.Options
+	"github.com/dgraph-io/badger/v4"
+)
+
+// encryptedIndexCacheSize is the default size of the index
+// cache of encrypted stores which badger requires to be set.
+const encryptedIndexCacheSize = 64 << 20
+
+// BucketOptions configure all the stores of a bucket. Options
+// which only make sense for one of the stores can be set
+// separately for the payload and the meta store.
+type BucketOptions struct {
+	// Logger is used by all stores. If nil
+	// the logging of the stores is disabled.
+	Logger badger.Logger
+
+	// SyncWrites syncs every write to disk
+	// before it's considered successful.
+	SyncWrites bool
+
+	// EncryptionKey encrypts all stores at rest
+	// using AES. The key has to be 16, 24 or 32
+	// bytes long. By default no encryption is used.
+	// It can't be used with PayloadBackendFiles.
+	EncryptionKey []byte
+
+	// EncryptionKeyRotationDuration is the duration
+	// after which the data keys of the stores are
+	// rotated. Default: 10 days.
+	EncryptionKeyRotationDuration time.Duration
+
+	// KeyProvider enables the envelope encryption of the
+	// payloads using a data key per owner. Destroying the
+	// keys of an owner makes all payloads of the owner
+	// unreadable. By default the payloads are not
+	// envelope encrypted.
+	KeyProvider KeyProvider
+
+	// GCInterval is the interval of the garbage collection
+	// running in the background to reclaim the disk space
+	// of removed objects. Zero disables the background
+	// garbage collection. Default: 10 minutes.
+	GCInterval time.Duration
+
+	// GCDiscardRatio is the ratio of removed data from which
+	// on a value log file is rewritten by the garbage
+	// collection. Default: 0.5.
+	GCDiscardRatio float64
+
+	// LifecycleInterval is the interval in which expired objects
+	// are removed and the lifecycle rules are applied. Zero
+	// disables the background removal. Default: 1 minute.
+	LifecycleInterval time.Duration
+
+	// PayloadBackend is the storage of the payloads of new
+	// buckets. Existing buckets keep their backend.
+	// Default: PayloadBackendBadger.
+	PayloadBackend PayloadBackend
+
+	// Payload are the options of the store containing the
+	// chunks of the payloads. They are ignored if the
+	// payloads are stored as files.
+	Payload StoreOptions
+
+	// Meta are the options of the store containing
+	// the metadata, names and indexes of the objects.
+	Meta StoreOptions
+}
+
+// StoreOptions configure one store of a bucket. Zero
+// values fall back to the defaults of badger.
+type StoreOptions struct {
+	// BlockCacheSize is the size of the block cache in bytes.
+	// It has to be greater than zero if encryption is enabled.
+	BlockCacheSize int64
+
+	// IndexCacheSize is the size of the index cache in bytes.
+	// By default all indexes are kept in memory if the stores
+	// aren't encrypted. Encrypted stores use a 64 MiB cache.
+	IndexCacheSize int64
+
+	// MemTableSize is the size of one memtable in bytes.
+	MemTableSize int64
+
+	// ValueLogFileSize is the size of one value log file in bytes.
+	ValueLogFileSize int64
+
+	// ValueThreshold is the size in bytes from which on a
+	// value is stored in the value log instead of the LSM tree.
+	ValueThreshold int64
+
+	// NumCompactors is the number of compaction workers.
+	NumCompactors int
+
+	// Tune allows to change any other option of the
+	// underlying badger store. The directory of the
+	// store can't be changed.
+	Tune func(opts badger.Options) badger.Options
+}
 
 func NewDefaultBucketOptions() BucketOptions {
-	return BucketOptions(badger.DefaultOptions(""))
+	defaults := badger.DefaultOptions("")
+	return BucketOptions{
+		Logger:                        defaults.Logger,
+		PayloadBackend:                PayloadBackendBadger,
+		GCInterval:                    10 * time.Minute,
+		GCDiscardRatio:                defaultGCDiscardRatio,
+		LifecycleInterval:             time.Minute,
+		EncryptionKeyRotationDuration: defaults.EncryptionKeyRotationDuration,
+	}
 }
 
-func (b *BucketOptions) overwriteDataDir(dir string) {
-	b.Dir = dir
-	b.ValueDir = dir
+// toBadgerOpts returns the options of the store located in dir.
+func (b BucketOptions) toBadgerOpts(dir string, s StoreOptions) badger.Options {
+	opts := badger.DefaultOptions(dir).
+		WithLogger(b.Logger).
+		WithSyncWrites(b.SyncWrites).
+		WithEncryptionKey(b.EncryptionKey)
+	if b.EncryptionKeyRotationDuration > 0 {
+		opts = opts.WithEncryptionKeyRotationDuration(b.EncryptionKeyRotationDuration)
+	}
+	if s.BlockCacheSize > 0 {
+		opts = opts.WithBlockCacheSize(s.BlockCacheSize)
+	}
+	if s.IndexCacheSize > 0 {
+		opts = opts.WithIndexCacheSize(s.IndexCacheSize)
+	} else if len(b.EncryptionKey) > 0 {
+		opts = opts.WithIndexCacheSize(encryptedIndexCacheSize)
+	}
+	if s.MemTableSize > 0 {
+		opts = opts.WithMemTableSize(s.MemTableSize)
+	}
+	if s.ValueLogFileSize > 0 {
+		opts = opts.WithValueLogFileSize(s.ValueLogFileSize)
+	}
+	if s.ValueThreshold > 0 {
+		opts = opts.WithValueThreshold(s.ValueThreshold)
+	}
+	if s.NumCompactors > 0 {
+		opts = opts.WithNumCompactors(s.NumCompactors)
+	}
+	if s.Tune != nil {
+		opts = s.Tune(opts)
+	}
+	opts.Dir = dir
+	opts.ValueDir = dir
+	return opts
 }
 
-func (b BucketOptions) toBadgerOpts() badger.Options {
-	return badger.Options(b)
+// toMemoryBadgerOpts returns the options of a store kept in memory.
+// In-memory stores don't have a value log so all values have to
+// be smaller than the value threshold.
+func (b BucketOptions) toMemoryBadgerOpts(s StoreOptions) badger.Options {
+	opts := b.toBadgerOpts("", s).WithInMemory(true)
+	opts.ValueThreshold = badger.DefaultOptions("").ValueThreshold
+	return opts
 }
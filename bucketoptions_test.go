@@ -0,0 +1,61 @@
+package objst
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func TestToBadgerOpts(t *testing.T) {
+	opts := testBucketOptions(nil)
+	opts.SyncWrites = true
+	opts.EncryptionKey = make([]byte, 32)
+	opts.Meta.MemTableSize = 16 << 20
+	opts.Meta.Tune = func(o badger.Options) badger.Options {
+		o.Dir = "ignored"
+		return o.WithNumVersionsToKeep(2)
+	}
+	payload := opts.toBadgerOpts("data", opts.Payload)
+	meta := opts.toBadgerOpts("meta", opts.Meta)
+	for _, o := range []badger.Options{payload, meta} {
+		if o.Logger != nil {
+			t.Fatalf("logger should be applied to all stores")
+		}
+		if !o.SyncWrites {
+			t.Fatalf("sync writes should be applied to all stores")
+		}
+		if len(o.EncryptionKey) != 32 {
+			t.Fatalf("encryption key should be applied to all stores")
+		}
+		if o.IndexCacheSize != encryptedIndexCacheSize {
+			t.Fatalf("encrypted stores should have an index cache. Got: %d", o.IndexCacheSize)
+		}
+	}
+	defaults := badger.DefaultOptions("")
+	if payload.MemTableSize != defaults.MemTableSize {
+		t.Fatalf("payload store should use the default memtable size. Got: %d", payload.MemTableSize)
+	}
+	if meta.MemTableSize != 16<<20 {
+		t.Fatalf("meta store should use its own memtable size. Got: %d", meta.MemTableSize)
+	}
+	if meta.NumVersionsToKeep != 2 {
+		t.Fatalf("tune should be applied to the meta store")
+	}
+	if meta.Dir != "meta" || meta.ValueDir != "meta" {
+		t.Fatalf("directory of the store should not be changeable. Got: %s", meta.Dir)
+	}
+}
+
+func TestOpenBucketWithStoreOptions(t *testing.T) {
+	b, _ := newTempBucket(t, func(opts *BucketOptions) {
+		opts.Payload.ValueLogFileSize = 16 << 20
+		opts.Meta.MemTableSize = 8 << 20
+	})
+	defer b.Shutdown()
+	if got := b.meta.(*badgerStore).db.Opts().MemTableSize; got != 8<<20 {
+		t.Fatalf("meta store should be opened with its options. Got: %d", got)
+	}
+	if got := b.blobs.(chunkStore).s.(*badgerStore).db.Opts().ValueLogFileSize; got != 16<<20 {
+		t.Fatalf("payload store should be opened with its options. Got: %d", got)
+	}
+}
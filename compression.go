@@ -0,0 +1,150 @@
+package objst
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the algorithm used to compress payloads.
+type Compression string
+
+const (
+	CompressionNone   Compression = ""
+	CompressionZstd   Compression = "zstd"
+	CompressionSnappy Compression = "snappy"
+)
+
+func (c Compression) isValid() bool {
+	switch c {
+	case CompressionNone, CompressionZstd, CompressionSnappy:
+		return true
+	}
+	return false
+}
+
+// CompressionPolicy decides which payloads are compressed
+// based on the content type of the object.
+type CompressionPolicy struct {
+	// Algorithm used to compress the payloads.
+	Algorithm Compression `json:"algorithm"`
+	// ContentTypes which will be compressed. A content type
+	// ending with `/*` matches all subtypes e.g. `text/*`.
+	ContentTypes []string `json:"contentTypes"`
+}
+
+// DefaultCompressionPolicy compresses common
+// textual content types using zstd.
+func DefaultCompressionPolicy() CompressionPolicy {
+	return CompressionPolicy{
+		Algorithm: CompressionZstd,
+		ContentTypes: []string{
+			"text/*",
+			"application/json",
+			"application/xml",
+			"application/javascript",
+			"image/svg+xml",
+		},
+	}
+}
+
+// algorithmFor returns the compression of payloads with the content type.
+func (p CompressionPolicy) algorithmFor(contentType string) Compression {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return CompressionNone
+	}
+	for _, ct := range p.ContentTypes {
+		if ct == mediaType {
+			return p.Algorithm
+		}
+		if prefix, ok := strings.CutSuffix(ct, "*"); ok && strings.HasPrefix(mediaType, prefix) {
+			return p.Algorithm
+		}
+	}
+	return CompressionNone
+}
+
+// encodingOf returns the compression of the stored payload.
+func encodingOf(meta *Metadata) Compression {
+	return Compression(meta.Get(metaKeyEncoding))
+}
+
+// SetCompression sets the policy used to compress the payloads of
+// new objects. Existing payloads are kept as they are and will be
+// decompressed transparently. The setting is persisted.
+func (b Bucket) SetCompression(p CompressionPolicy) error {
+	if !p.Algorithm.isValid() {
+		return fmt.Errorf("%w: %s", ErrUnknownCompression, p.Algorithm)
+	}
+	return b.updateSettings(func(s *settings) {
+		s.Compression = p
+	})
+}
+
+// Compression returns the compression policy of the bucket.
+func (b Bucket) Compression() CompressionPolicy {
+	return b.settings.compression()
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// compress returns a writer compressing the written data
+// into w. The writer has to be closed to flush the data.
+func compress(w io.Writer, enc Compression) (io.WriteCloser, error) {
+	switch enc {
+	case CompressionNone:
+		return nopWriteCloser{w}, nil
+	case CompressionZstd:
+		return zstd.NewWriter(w)
+	case CompressionSnappy:
+		return snappy.NewBufferedWriter(w), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownCompression, enc)
+}
+
+type zstdReadCloser struct {
+	*zstd.Decoder
+	r io.Closer
+}
+
+func (z zstdReadCloser) Close() error {
+	z.Decoder.Close()
+	return z.r.Close()
+}
+
+type snappyReadCloser struct {
+	*snappy.Reader
+	r io.Closer
+}
+
+func (s snappyReadCloser) Close() error {
+	return s.r.Close()
+}
+
+// decompress returns a reader decompressing the data of r.
+func decompress(r io.ReadCloser, enc Compression) (io.ReadCloser, error) {
+	switch enc {
+	case CompressionNone:
+		return r, nil
+	case CompressionZstd:
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return zstdReadCloser{Decoder: d, r: r}, nil
+	case CompressionSnappy:
+		return snappyReadCloser{Reader: snappy.NewReader(r), r: r}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownCompression, enc)
+}
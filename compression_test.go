@@ -0,0 +1,158 @@
+package objst
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestCompression(t *testing.T) {
+	tests := []struct {
+		name string
+		alg  Compression
+	}{
+		{
+			name: "zstd",
+			alg:  CompressionZstd,
+		},
+		{
+			name: "snappy",
+			alg:  CompressionSnappy,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b, _ := newTempBucket(t, nil)
+			defer b.Shutdown()
+			p := DefaultCompressionPolicy()
+			p.Algorithm = tc.alg
+			if err := b.SetCompression(p); err != nil {
+				t.Error(err)
+				return
+			}
+			o := tEnv.obj()
+			pl := bytes.Repeat([]byte("compressible "), 1000)
+			o.pl.Reset()
+			o.Write(pl)
+			if err := b.Create(o); err != nil {
+				t.Error(err)
+				return
+			}
+			if encodingOf(o.meta) != tc.alg {
+				t.Fatalf("payload should be compressed. Got: %s", encodingOf(o.meta))
+			}
+			cr, err := b.blobs.Open(blobOf(o.meta))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			stored, err := io.ReadAll(cr)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(stored) >= len(pl) {
+				t.Fatalf("stored payload is not compressed. Got: %d bytes", len(stored))
+			}
+			got, err := b.GetPayload(o.ID())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !bytes.Equal(got, pl) {
+				t.Fatalf("payload is not decompressed correctly")
+			}
+		})
+	}
+}
+
+func TestCompressionPolicy(t *testing.T) {
+	p := DefaultCompressionPolicy()
+	tests := []struct {
+		name        string
+		contentType string
+		want        Compression
+	}{
+		{
+			name:        "wildcard",
+			contentType: "text/plain; charset=utf-8",
+			want:        CompressionZstd,
+		},
+		{
+			name:        "exact",
+			contentType: "application/json",
+			want:        CompressionZstd,
+		},
+		{
+			name:        "incompressible",
+			contentType: "image/png",
+			want:        CompressionNone,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := p.algorithmFor(tc.contentType); got != tc.want {
+				t.Fatalf("compression is not correct. Got: %s. Expected: %s", got, tc.want)
+			}
+		})
+	}
+	if err := tEnv.b.SetCompression(CompressionPolicy{Algorithm: "lz4"}); !errors.Is(err, ErrUnknownCompression) {
+		t.Fatalf("unknown compression should be rejected. Got: %v", err)
+	}
+}
+
+func TestHTTPReadEncoded(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	ts := httptest.NewServer(NewHTTPHandler(b, DefaultHTTPHandlerOptions()))
+	defer ts.Close()
+	if err := b.SetCompression(DefaultCompressionPolicy()); err != nil {
+		t.Error(err)
+		return
+	}
+	o := tEnv.obj()
+	if err := b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	target, err := url.JoinPath(ts.URL, route, "read", o.ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	r, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	r.Header.Set(headerAcceptEncoding, "gzip, zstd")
+	res, err := ts.Client().Do(r)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer res.Body.Close()
+	if res.Header.Get(headerContentEncoding) != string(CompressionZstd) {
+		t.Fatalf("payload should be served compressed. Got: %s", res.Header.Get(headerContentEncoding))
+	}
+	d, err := zstd.NewReader(res.Body)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer d.Close()
+	pl, err := io.ReadAll(d)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(pl, o.Payload()) {
+		t.Fatalf("payload is not the same. Got: %s. Expected: %s", pl, o.Payload())
+	}
+}
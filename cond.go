@@ -0,0 +1,254 @@
+package objst
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// Cond is a condition the metadata of an object has to fulfill
+// to match a query. Conditions can be grouped using All, Any
+// and Not to build arbitrarily nested predicates.
+type Cond interface {
+	match(meta *Metadata) bool
+}
+
+type eqCond struct {
+	k MetaKey
+	v string
+}
+
+// Eq matches objects whose value of k is equal to v.
+func Eq(k MetaKey, v string) Cond {
+	return eqCond{k: k, v: v}
+}
+
+func (c eqCond) match(meta *Metadata) bool {
+	return meta.Has(c.k) && meta.Get(c.k) == c.v
+}
+
+// Ne matches objects whose value of k is not equal
+// to v including objects which don't have the key k.
+func Ne(k MetaKey, v string) Cond {
+	return Not(Eq(k, v))
+}
+
+type prefixCond struct {
+	k      MetaKey
+	prefix string
+}
+
+// Prefix matches objects whose value of k starts with prefix.
+func Prefix(k MetaKey, prefix string) Cond {
+	return prefixCond{k: k, prefix: prefix}
+}
+
+func (c prefixCond) match(meta *Metadata) bool {
+	return meta.Has(c.k) && strings.HasPrefix(meta.Get(c.k), c.prefix)
+}
+
+type existsCond struct {
+	k MetaKey
+}
+
+// Exists matches objects which have the key k.
+func Exists(k MetaKey) Cond {
+	return existsCond{k: k}
+}
+
+func (c existsCond) match(meta *Metadata) bool {
+	return meta.Has(c.k)
+}
+
+type cmpOp int
+
+const (
+	opGt cmpOp = iota + 1
+	opGte
+	opLt
+	opLte
+)
+
+type cmpCond struct {
+	k  MetaKey
+	v  string
+	op cmpOp
+}
+
+// Gt matches objects whose value of k is greater than v. Numbers and
+// timestamps in the RFC3339 format are compared by their value and
+// all other values lexicographically.
+func Gt(k MetaKey, v string) Cond {
+	return cmpCond{k: k, v: v, op: opGt}
+}
+
+// Gte matches objects whose value of k is greater than or equal to v.
+func Gte(k MetaKey, v string) Cond {
+	return cmpCond{k: k, v: v, op: opGte}
+}
+
+// Lt matches objects whose value of k is less than v.
+func Lt(k MetaKey, v string) Cond {
+	return cmpCond{k: k, v: v, op: opLt}
+}
+
+// Lte matches objects whose value of k is less than or equal to v.
+func Lte(k MetaKey, v string) Cond {
+	return cmpCond{k: k, v: v, op: opLte}
+}
+
+// After matches objects whose timestamp of k is after t.
+func After(k MetaKey, t time.Time) Cond {
+	return Gt(k, formatTime(t))
+}
+
+// Before matches objects whose timestamp of k is before t.
+func Before(k MetaKey, t time.Time) Cond {
+	return Lt(k, formatTime(t))
+}
+
+func (c cmpCond) match(meta *Metadata) bool {
+	if !meta.Has(c.k) {
+		return false
+	}
+	res := compareValues(meta.Get(c.k), c.v)
+	switch c.op {
+	case opGt:
+		return res > 0
+	case opGte:
+		return res >= 0
+	case opLt:
+		return res < 0
+	default:
+		return res <= 0
+	}
+}
+
+type inCond struct {
+	k  MetaKey
+	vs []string
+}
+
+// In matches objects whose value of k is one of vs.
+func In(k MetaKey, vs ...string) Cond {
+	return inCond{k: k, vs: vs}
+}
+
+func (c inCond) match(meta *Metadata) bool {
+	return meta.Has(c.k) && slices.Contains(c.vs, meta.Get(c.k))
+}
+
+type matchCond struct {
+	k  MetaKey
+	re *regexp.Regexp
+	// err is the compilation error of the pattern
+	err error
+}
+
+// Match matches objects whose value of k matches the
+// anchored regular expression pattern. The pattern is
+// compiled once for the whole query.
+func Match(k MetaKey, pattern string) Cond {
+	re, err := regexp.Compile(metaPattern(pattern))
+	return matchCond{k: k, re: re, err: err}
+}
+
+func (c matchCond) match(meta *Metadata) bool {
+	return meta.Has(c.k) && c.re.MatchString(meta.Get(c.k))
+}
+
+type allCond struct {
+	conds []Cond
+}
+
+// All matches objects fulfilling all conditions.
+func All(conds ...Cond) Cond {
+	return allCond{conds: conds}
+}
+
+func (c allCond) match(meta *Metadata) bool {
+	for _, cond := range c.conds {
+		if !cond.match(meta) {
+			return false
+		}
+	}
+	return true
+}
+
+type anyCond struct {
+	conds []Cond
+}
+
+// Any matches objects fulfilling at least one condition.
+func Any(conds ...Cond) Cond {
+	return anyCond{conds: conds}
+}
+
+func (c anyCond) match(meta *Metadata) bool {
+	for _, cond := range c.conds {
+		if cond.match(meta) {
+			return true
+		}
+	}
+	return false
+}
+
+type notCond struct {
+	cond Cond
+}
+
+// Not matches objects not fulfilling the condition.
+func Not(c Cond) Cond {
+	return notCond{cond: c}
+}
+
+func (c notCond) match(meta *Metadata) bool {
+	return !c.cond.match(meta)
+}
+
+// validateCond returns the first error found in the condition.
+func validateCond(c Cond) error {
+	switch c := c.(type) {
+	case matchCond:
+		return c.err
+	case allCond:
+		return validateConds(c.conds)
+	case anyCond:
+		return validateConds(c.conds)
+	case notCond:
+		return validateCond(c.cond)
+	case nil:
+		return ErrNilCond
+	}
+	return nil
+}
+
+func validateConds(conds []Cond) error {
+	for _, c := range conds {
+		if err := validateCond(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// paramsCond converts the params of the query
+// to a condition with the same semantics.
+func paramsCond(params *Metadata, act action) Cond {
+	conds := make([]Cond, 0, len(params.data))
+	for k, v := range params.data {
+		if k == MetaKeyID {
+			// ids are validated uuids which
+			// can only match literally.
+			conds = append(conds, Eq(k, v))
+			continue
+		}
+		conds = append(conds, Match(k, v))
+	}
+	if act == And {
+		return All(conds...)
+	}
+	return Any(conds...)
+}
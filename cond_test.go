@@ -0,0 +1,78 @@
+package objst
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCondMatch(t *testing.T) {
+	const (
+		size  MetaKey = "size"
+		color MetaKey = "color"
+	)
+	now := time.Now()
+	meta := NewMetadata()
+	meta.set(size, "120")
+	meta.set(color, "dark-blue")
+	meta.set(MetaKeyCreatedAt, now.UTC().Format(time.RFC3339Nano))
+	tests := []struct {
+		name string
+		c    Cond
+		want bool
+	}{
+		{name: "equal", c: Eq(color, "dark-blue"), want: true},
+		{name: "not equal", c: Ne(color, "dark-blue"), want: false},
+		{name: "not equal missing key", c: Ne("missing", "value"), want: true},
+		{name: "prefix", c: Prefix(color, "dark"), want: true},
+		{name: "exists", c: Exists("missing"), want: false},
+		{name: "numeric greater", c: Gt(size, "99"), want: true},
+		{name: "numeric less or equal", c: Lte(size, "120"), want: true},
+		{name: "time after", c: After(MetaKeyCreatedAt, now.Add(-time.Hour)), want: true},
+		{name: "time before", c: Before(MetaKeyCreatedAt, now.Add(-time.Hour)), want: false},
+		{name: "in", c: In(color, "red", "dark-blue"), want: true},
+		{name: "match", c: Match(color, "dark-.*"), want: true},
+		{
+			name: "nested groups",
+			c:    All(Any(Eq(color, "red"), Prefix(color, "dark")), Not(Lt(size, "100"))),
+			want: true,
+		},
+		{name: "empty any", c: Any(), want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.c.match(meta); got != test.want {
+				t.Fatalf("condition result is not as expected. Got: %t. Expected: %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestQueryWhere(t *testing.T) {
+	const rank MetaKey = "rank"
+	owner := tEnv.owner()
+	for _, r := range []string{"1", "5", "10", "50"} {
+		o := tEnv.obj()
+		o.meta.set(MetaKeyOwner, owner)
+		o.SetMetaKey(rank, r)
+		if err := tEnv.b.Create(o); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	q := NewQuery().Owner(owner).Where(Any(Lt(rank, "5"), All(Gte(rank, "10"), Not(Eq(rank, "50")))))
+	objs, err := tEnv.b.Execute(q)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(objs) != 2 {
+		t.Fatalf("not the right number fetched. Got: %d. Expected: %d", len(objs), 2)
+	}
+}
+
+func TestQueryInvalidPattern(t *testing.T) {
+	q := NewQuery().Where(Match("foo", "("))
+	if _, err := tEnv.b.Execute(q); err == nil {
+		t.Fatalf("invalid pattern should be reported")
+	}
+}
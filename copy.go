@@ -0,0 +1,91 @@
+package objst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Copy creates a new object with the name and owner using the
+// payload and metadata of the source object. The overrides are
+// applied to the user defined metadata of the copy where keys
+// with an empty value will be removed. The payload is shared
+// between both objects until one of them is replaced or deleted.
+// If the owner is empty the owner of the source object is used.
+// If the payloads are envelope encrypted and the owner differs
+// from the source, the payload is copied using the data key of
+// the owner instead of being shared.
+func (b Bucket) Copy(srcID, name, owner string, overrides map[MetaKey]string) (*Descriptor, error) {
+	if !isValidObjectName(name) {
+		return nil, ErrInvalidNamePattern
+	}
+	src, err := b.GetMeta(srcID)
+	if err != nil {
+		return nil, err
+	}
+	if owner == "" {
+		owner = src.Get(MetaKeyOwner)
+	}
+	var (
+		blob string
+		stat payloadStat
+	)
+	if b.needsReencryption(src, owner) {
+		blob, stat, err = b.reencrypt(src, owner)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var (
+		meta    *Metadata
+		orphans []string
+	)
+	err = b.meta.Update(func(txn storeTxn) error {
+		cur, err := b.getMeta(txn, srcID)
+		if err != nil {
+			return err
+		}
+		if blobOf(cur) != blobOf(src) {
+			return fmt.Errorf("%w: payload of %s has been replaced during the copy", ErrPreconditionFailed, srcID)
+		}
+		meta = cur.clone()
+		meta.set(metaKeyBlob, blobOf(cur))
+		meta.set(MetaKeyID, uuid.NewString())
+		meta.set(MetaKeyName, name)
+		meta.set(MetaKeyOwner, owner)
+		delete(meta.data, MetaKeyVersion)
+		for k, v := range overrides {
+			if v == "" {
+				meta.Del(k)
+				continue
+			}
+			meta.Set(k, v)
+		}
+		if !meta.Has(MetaKeyContentType) {
+			return ErrContentTypeNotExist
+		}
+		if blob != "" {
+			meta.set(metaKeyBlob, blob)
+			stat.stamp(meta)
+		}
+		now := formatTime(time.Now())
+		meta.set(MetaKeyCreatedAt, now)
+		meta.set(MetaKeyUpdatedAt, now)
+		orphans, err = b.commitObject(txn, meta)
+		if err != nil {
+			return err
+		}
+		if blob != "" {
+			return nil
+		}
+		return retainBlob(txn, blobOf(meta))
+	})
+	if err != nil && blob != "" {
+		return nil, b.rollback(err, blob)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Descriptor{meta: meta}, b.resolveIntents(orphans...)
+}
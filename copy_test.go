@@ -0,0 +1,72 @@
+package objst
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	o := tEnv.obj()
+	o.SetMetaKey("foo", "bar")
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	owner := tEnv.owner()
+	d, err := tEnv.b.Copy(o.ID(), o.Name(), owner, map[MetaKey]string{"foo": "", "baz": "qux"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if d.ID() == o.ID() || d.Owner() != owner || d.HasMetaKey("foo") || d.GetMetaKey("baz") != "qux" {
+		t.Fatalf("copy is not correct. Got: %+v", d.ToModel())
+	}
+	if _, err := tEnv.b.Copy(o.ID(), o.Name(), "", nil); err == nil {
+		t.Fatalf("copy should detect the name conflict")
+	}
+	// the payload is shared until the source is deleted
+	if err := tEnv.b.DeleteByID(o.ID()); err != nil {
+		t.Error(err)
+		return
+	}
+	pl, err := tEnv.b.GetPayload(d.ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(pl, o.Payload()) {
+		t.Fatalf("payload of the copy is not correct. Got: %s. Expected: %s", pl, o.Payload())
+	}
+	if err := tEnv.b.DeleteByID(d.ID()); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := tEnv.b.blobs.Open(blobOf(o.meta)); err == nil {
+		t.Fatalf("payload should be removed with the last reference")
+	}
+}
+
+func TestCopyReplace(t *testing.T) {
+	o := tEnv.obj()
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	d, err := tEnv.b.Copy(o.ID(), tEnv.name(), "", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := tEnv.b.Replace(d.ID(), bytes.NewReader(tEnv.payload(20)), ""); err != nil {
+		t.Error(err)
+		return
+	}
+	pl, err := tEnv.b.GetPayload(o.ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(pl, o.Payload()) {
+		t.Fatalf("payload of the source should be kept after replacing the copy")
+	}
+}
@@ -0,0 +1,50 @@
+package objst
+
+import "errors"
+
+// dedupe replaces the payload of the version with an existing
+// payload with the same checksum if the deduplication is enabled.
+// Otherwise the payload is registered for future deduplications.
+// The replaced payload keeps its write intent and has to be
+// removed after the transaction has been committed.
+func (b Bucket) dedupe(txn storeTxn, meta *Metadata) error {
+	if !b.IsDeduplicated() {
+		return nil
+	}
+	key := casKey(meta)
+	blob, err := txn.Get(key)
+	if errors.Is(err, errKeyNotFound) {
+		return txn.Set(key, []byte(blobOf(meta)))
+	}
+	if err != nil {
+		return err
+	}
+	meta.set(metaKeyBlob, string(blob))
+	return retainBlob(txn, string(blob))
+}
+
+// forgetBlob removes the payload of the version from the
+// content addressed payloads if it has been registered.
+func forgetBlob(txn storeTxn, meta *Metadata) error {
+	key := casKey(meta)
+	blob, err := txn.Get(key)
+	if errors.Is(err, errKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if string(blob) != blobOf(meta) {
+		return nil
+	}
+	return txn.Delete(key)
+}
+
+// duplicateOf returns the written payload if
+// it has been replaced by an equal payload.
+func duplicateOf(blob string, meta *Metadata) []string {
+	if blobOf(meta) == blob {
+		return nil
+	}
+	return []string{blob}
+}
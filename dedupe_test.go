@@ -0,0 +1,67 @@
+package objst
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeduplication(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	if err := b.SetDeduplication(true); err != nil {
+		t.Error(err)
+		return
+	}
+	pl := tEnv.payload(10)
+	objs := make([]*Object, 0, 3)
+	for i := 0; i < 3; i++ {
+		o, _ := NewObject(tEnv.name(), tEnv.owner())
+		o.Write(pl)
+		objs = append(objs, o)
+	}
+	if err := b.Create(objs[0]); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := b.BatchCreate(objs[1:]); err != nil {
+		t.Error(err)
+		return
+	}
+	blob := blobOf(objs[0].meta)
+	for _, o := range objs[1:] {
+		if blobOf(o.meta) != blob {
+			t.Fatalf("equal payloads should be stored once")
+		}
+	}
+	for _, o := range objs[:2] {
+		if err := b.DeleteByID(o.ID()); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	got, err := b.GetPayload(objs[2].ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(got, pl) {
+		t.Fatalf("shared payload should be kept until the last reference is deleted")
+	}
+	if err := b.DeleteByID(objs[2].ID()); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := b.blobs.Open(blob); err == nil {
+		t.Fatalf("payload should be removed with the last reference")
+	}
+	// the payload is stored again after it has been removed
+	o, _ := NewObject(tEnv.name(), tEnv.owner())
+	o.Write(pl)
+	if err := b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := b.GetPayload(o.ID()); err != nil {
+		t.Fatal(err)
+	}
+}
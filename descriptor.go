@@ -0,0 +1,75 @@
+package objst
+
+import "time"
+
+// Descriptor is a lightweight description of an object
+// containing only the metadata without the payload. The
+// payload can be loaded using the id of the descriptor.
+type Descriptor struct {
+	meta *Metadata
+}
+
+func (d Descriptor) ID() string {
+	return d.meta.Get(MetaKeyID)
+}
+
+func (d Descriptor) Name() string {
+	return d.meta.Get(MetaKeyName)
+}
+
+func (d Descriptor) Owner() string {
+	return d.meta.Get(MetaKeyOwner)
+}
+
+// Size returns the size of the payload in bytes.
+func (d Descriptor) Size() int64 {
+	return sizeOf(d.meta)
+}
+
+// Checksum returns the hex encoded
+// SHA-256 hash of the payload.
+func (d Descriptor) Checksum() string {
+	return d.meta.Get(MetaKeyChecksum)
+}
+
+// CreatedAt returns the time the object was created.
+func (d Descriptor) CreatedAt() time.Time {
+	return parseTime(d.meta.Get(MetaKeyCreatedAt))
+}
+
+// UpdatedAt returns the time the object was last modified.
+func (d Descriptor) UpdatedAt() time.Time {
+	return parseTime(d.meta.Get(MetaKeyUpdatedAt))
+}
+
+// ExpiresAt returns the time after which the object will
+// be removed. It's zero if the object doesn't expire.
+func (d Descriptor) ExpiresAt() time.Time {
+	return parseTime(d.meta.Get(MetaKeyExpiresAt))
+}
+
+// Version returns the version of the object.
+func (d Descriptor) Version() int {
+	return versionOf(d.meta)
+}
+
+// ETag returns the entity tag of the object which
+// changes with every modification of the object.
+func (d Descriptor) ETag() string {
+	return etagOf(d.meta)
+}
+
+// GetMetaKey returns the corresponding value of the provided key.
+func (d Descriptor) GetMetaKey(k MetaKey) string {
+	return d.meta.Get(k)
+}
+
+// HasMetaKey check if the meta data of the
+// object contains the given key.
+func (d Descriptor) HasMetaKey(k MetaKey) bool {
+	return d.meta.Has(k)
+}
+
+func (d Descriptor) ToModel() *objectModel {
+	return newObjectModel(d.meta)
+}
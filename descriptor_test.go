@@ -0,0 +1,52 @@
+package objst
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	o := tEnv.obj()
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	d, err := tEnv.b.Describe(o.ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	sum := sha256.Sum256(o.Payload())
+	if d.Checksum() != hex.EncodeToString(sum[:]) {
+		t.Fatalf("checksum is not the same. Got: %s. Expected: %x", d.Checksum(), sum)
+	}
+	if d.Size() != int64(len(o.Payload())) {
+		t.Fatalf("size is not the same. Got: %d. Expected: %d", d.Size(), len(o.Payload()))
+	}
+}
+
+func TestList(t *testing.T) {
+	owner := tEnv.owner()
+	objs := tEnv.nObj(3)
+	for _, o := range objs {
+		o.meta.set(MetaKeyOwner, owner)
+		if err := tEnv.b.Create(o); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	page, err := tEnv.b.List(NewQuery().Owner(owner).SortBy(MetaKeyName, Asc))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(page.Items) != len(objs) {
+		t.Fatalf("not the right number listed. Got: %d. Expected: %d", len(page.Items), len(objs))
+	}
+	for i := 1; i < len(page.Items); i++ {
+		if page.Items[i-1].Name() > page.Items[i].Name() {
+			t.Fatalf("descriptors are not sorted by name")
+		}
+	}
+}
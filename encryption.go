@@ -0,0 +1,227 @@
+package objst
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// segmentSize is the size of the plaintext
+	// of one encrypted segment of a payload.
+	segmentSize = 64 << 10
+	// saltSize is the size of the random salt used
+	// to derive the key of an encrypted payload.
+	saltSize = 16
+)
+
+// payloadKey derives the key of one payload from the data key
+// so the nonces of the segments never repeat for a data key.
+func payloadKey(dataKey, salt []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, dataKey)
+	mac.Write(salt)
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// segmentNonce returns the nonce of the n-th segment. The last
+// segment uses a different nonce to detect truncated payloads.
+func segmentNonce(n uint64, last bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce, n)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// encryptWriter encrypts the written data in segments
+// using AES-GCM. The writer has to be closed to write
+// the last segment.
+type encryptWriter struct {
+	w    io.Writer
+	aead cipher.AEAD
+	n    uint64
+	buf  []byte
+}
+
+func encrypt(w io.Writer, dataKey []byte) (io.WriteCloser, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := payloadKey(dataKey, salt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(salt); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:    w,
+		aead: aead,
+		buf:  make([]byte, 0, segmentSize),
+	}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		// a full segment is only sealed if more data follows
+		// because the last segment has to be marked as such.
+		if len(e.buf) == segmentSize {
+			if err := e.seal(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(e.buf[len(e.buf):segmentSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (e *encryptWriter) Close() error {
+	return e.seal(true)
+}
+
+func (e *encryptWriter) seal(last bool) error {
+	sealed := e.aead.Seal(nil, segmentNonce(e.n, last), e.buf, nil)
+	if _, err := e.w.Write(sealed); err != nil {
+		return err
+	}
+	e.n++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+// decryptReader decrypts the segments of a payload
+// encrypted by an encryptWriter.
+type decryptReader struct {
+	src   *bufio.Reader
+	r     io.Closer
+	aead  cipher.AEAD
+	n     uint64
+	buf   []byte
+	plain []byte
+	done  bool
+}
+
+func decrypt(r io.ReadCloser, dataKey []byte) (io.ReadCloser, error) {
+	src := bufio.NewReader(r)
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(src, salt); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryptionFailed, err)
+	}
+	aead, err := payloadKey(dataKey, salt)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{
+		src:  src,
+		r:    r,
+		aead: aead,
+		buf:  make([]byte, segmentSize+aead.Overhead()),
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// open decrypts the next segment.
+func (d *decryptReader) open() error {
+	n, err := io.ReadFull(d.src, d.buf)
+	last := errors.Is(err, io.ErrUnexpectedEOF)
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: payload is truncated", ErrDecryptionFailed)
+	}
+	if err != nil && !last {
+		return err
+	}
+	if !last {
+		_, err := d.src.Peek(1)
+		last = errors.Is(err, io.EOF)
+	}
+	plain, err := d.aead.Open(d.buf[:0], segmentNonce(d.n, last), d.buf[:n], nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDecryptionFailed, err)
+	}
+	d.plain = plain
+	d.done = last
+	d.n++
+	return nil
+}
+
+func (d *decryptReader) Close() error {
+	return d.r.Close()
+}
+
+// isEncrypted reports if the payload of the
+// version is envelope encrypted.
+func isEncrypted(meta *Metadata) bool {
+	return meta.Has(metaKeyKeyID)
+}
+
+// dataKeyOf returns the data key used to encrypt the payload of the version.
+func (b Bucket) dataKeyOf(meta *Metadata) ([]byte, error) {
+	if b.keys == nil {
+		return nil, ErrMissingKeyProvider
+	}
+	return b.keys.Key(meta.Get(metaKeyKeyID))
+}
+
+// needsReencryption reports if the payload of the version has to
+// be encrypted again if the owner of the version changes to owner.
+func (b Bucket) needsReencryption(meta *Metadata, owner string) bool {
+	return b.keys != nil && meta.Get(MetaKeyOwner) != owner
+}
+
+// reencrypt writes a copy of the payload of the version encrypted
+// using the data key of the owner. The write intent of the copy
+// has to be removed when the copy is committed.
+func (b Bucket) reencrypt(meta *Metadata, owner string) (string, payloadStat, error) {
+	r, err := b.openPayload(meta)
+	if err != nil {
+		return "", payloadStat{}, err
+	}
+	defer r.Close()
+	target := meta.clone()
+	target.set(MetaKeyOwner, owner)
+	f, err := b.formatFor(target)
+	if err != nil {
+		return "", payloadStat{}, err
+	}
+	blob := uuid.NewString()
+	if err := b.insertIntents(blob); err != nil {
+		return "", payloadStat{}, err
+	}
+	stat, err := b.insertPayload(blob, r, f)
+	if err != nil {
+		return "", stat, b.rollback(err, blob)
+	}
+	return blob, stat, nil
+}
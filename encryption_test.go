@@ -0,0 +1,180 @@
+package objst
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, dataKeySize)
+	tests := []struct {
+		name string
+		size int
+	}{
+		{
+			name: "empty",
+			size: 0,
+		},
+		{
+			name: "one segment",
+			size: segmentSize,
+		},
+		{
+			name: "multiple segments",
+			size: 2*segmentSize + 1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pl := tEnv.payload(tc.size)
+			buf := new(bytes.Buffer)
+			w, err := encrypt(buf, key)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := w.Write(pl); err != nil {
+				t.Error(err)
+				return
+			}
+			if err := w.Close(); err != nil {
+				t.Error(err)
+				return
+			}
+			sealed := buf.Bytes()
+			r, err := decrypt(io.NopCloser(bytes.NewReader(sealed)), key)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !bytes.Equal(got, pl) {
+				t.Fatalf("decrypted payload is not the same. Got: %d bytes. Expected: %d bytes", len(got), len(pl))
+			}
+			// cutting of the last segment has to be detected
+			truncated := sealed[:len(sealed)-1]
+			if tc.size > segmentSize {
+				truncated = sealed[:saltSize+segmentSize+16]
+			}
+			r, err = decrypt(io.NopCloser(bytes.NewReader(truncated)), key)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := io.ReadAll(r); !errors.Is(err, ErrDecryptionFailed) {
+				t.Fatalf("truncated payload should be detected. Got: %v", err)
+			}
+		})
+	}
+}
+
+func TestEnvelopeEncryption(t *testing.T) {
+	kp := NewMemoryKeyProvider()
+	b, _ := newTempBucket(t, func(opts *BucketOptions) {
+		opts.KeyProvider = kp
+	})
+	defer b.Shutdown()
+	objs := tEnv.nObj(2)
+	pl := bytes.Repeat([]byte("secret"), segmentSize)
+	objs[0].pl.Reset()
+	objs[0].Write(pl)
+	if err := b.BatchCreate(objs); err != nil {
+		t.Error(err)
+		return
+	}
+	cr, err := b.blobs.Open(blobOf(objs[0].meta))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	stored, err := io.ReadAll(cr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if bytes.Contains(stored, []byte("secret")) {
+		t.Fatalf("stored payload is not encrypted")
+	}
+	got, err := b.GetPayload(objs[0].ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(got, pl) {
+		t.Fatalf("payload is not decrypted correctly")
+	}
+	kp.Shred(objs[0].Owner())
+	if _, err := b.GetPayload(objs[0].ID()); !errors.Is(err, ErrDataKeyNotFound) {
+		t.Fatalf("payload of a shredded owner should be unreadable. Got: %v", err)
+	}
+	if _, err := b.GetPayload(objs[1].ID()); err != nil {
+		t.Fatalf("payloads of other owners should be readable. Got: %v", err)
+	}
+}
+
+func TestTransferReencrypts(t *testing.T) {
+	kp := NewMemoryKeyProvider()
+	b, _ := newTempBucket(t, func(opts *BucketOptions) {
+		opts.KeyProvider = kp
+	})
+	defer b.Shutdown()
+	o := tEnv.obj()
+	if err := b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	owner := tEnv.owner()
+	if _, err := b.Transfer(o.ID(), owner); err != nil {
+		t.Error(err)
+		return
+	}
+	d, err := b.Copy(o.ID(), tEnv.name(), tEnv.owner(), nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	kp.Shred(o.Owner())
+	for _, id := range []string{o.ID(), d.ID()} {
+		got, err := b.GetPayload(id)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !bytes.Equal(got, o.Payload()) {
+			t.Fatalf("payload is not the same. Got: %s. Expected: %s", got, o.Payload())
+		}
+	}
+	if _, err := b.blobs.Open(blobOf(o.meta)); err == nil {
+		t.Fatalf("payload encrypted with the key of the previous owner should be removed")
+	}
+}
+
+func TestEncryptionAtRest(t *testing.T) {
+	opts := testBucketOptions(func(opts *BucketOptions) {
+		opts.EncryptionKey = bytes.Repeat([]byte{1}, 32)
+	})
+	dir := t.TempDir()
+	b, err := OpenBucketAt(dir, opts)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := b.Create(tEnv.obj()); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := b.Shutdown(); err != nil {
+		t.Error(err)
+		return
+	}
+	opts.EncryptionKey = bytes.Repeat([]byte{2}, 32)
+	if _, err := OpenBucketAt(dir, opts); err == nil {
+		t.Fatalf("bucket should not be readable using another key")
+	}
+}
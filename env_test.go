@@ -30,10 +30,7 @@ func newTestEnv() (*testEnv, error) {
 	tEnv := testEnv{
 		ContentType: "test/text",
 	}
-	opts := NewDefaultBucketOptions()
-	// turn of default loggin of badger
-	opts.Logger = nil
-	b, err := NewBucket(opts)
+	b, err := NewMemoryBucket(testBucketOptions(nil))
 	if err != nil {
 		return nil, err
 	}
@@ -44,6 +41,33 @@ func newTestEnv() (*testEnv, error) {
 	return &tEnv, nil
 }
 
+// testBucketOptions returns the default options without the
+// logging of badger adjusted by configure which can be nil.
+func testBucketOptions(configure func(opts *BucketOptions)) BucketOptions {
+	opts := NewDefaultBucketOptions()
+	opts.Logger = nil
+	if configure != nil {
+		configure(&opts)
+	}
+	return opts
+}
+
+// newTempBucket opens a bucket in a temporary directory for tests
+// which have to change the settings or options of a bucket.
+func newTempBucket(t *testing.T, configure func(opts *BucketOptions)) (*Bucket, string) {
+	dir := t.TempDir()
+	return reopenTempBucket(t, dir, configure), dir
+}
+
+// reopenTempBucket opens the existing bucket in dir.
+func reopenTempBucket(t *testing.T, dir string, configure func(opts *BucketOptions)) *Bucket {
+	b, err := OpenBucketAt(dir, testBucketOptions(configure))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
 func (t testEnv) owner() string {
 	return uuid.NewString()
 }
@@ -111,9 +135,6 @@ func (t testEnv) destroy() error {
 	if err := t.b.Shutdown(); err != nil {
 		return err
 	}
-	if err := os.RemoveAll(t.b.BasePath); err != nil {
-		return err
-	}
 	t.ts.Close()
 	return nil
 }
@@ -5,13 +5,51 @@ import (
 	"fmt"
 )
 
+// Bucket errors
+var (
+	ErrInvalidBucketName     = fmt.Errorf("bucket name must match the following regex pattern: %s", bucketNamePattern)
+	ErrInvalidBucketLayout   = errors.New("directory is not a valid bucket")
+	ErrUnknownPayloadBackend = errors.New("unknown payload backend")
+	ErrUnencryptedBackend    = errors.New("payload backend can't be encrypted at rest. Use a key provider instead")
+)
+
 // Object errors
 var (
+	ErrObjectNotFound          = errors.New("object doesn't exist")
+	ErrNameConflict            = errors.New("object with the name exists for the owner")
 	ErrContentTypeNotExist     = errors.New("missing content type metadata")
 	ErrEmptyPayload            = errors.New("object doesn't contain any payload")
 	ErrObjectIsImmutable       = errors.New("object is immutable. Create a new object")
-	ErrMustIncludeOwnerAndName = errors.New("object is immutable. Create a new object")
+	ErrMustIncludeOwnerAndName = errors.New("object must include an owner and a name")
+	ErrChecksumMismatch        = errors.New("checksum of the payload doesn't match. The payload might be corrupted")
 	ErrInvalidNamePattern      = fmt.Errorf("object name must match the following regex pattern: %s", objectNamePattern)
+	ErrPreconditionFailed      = errors.New("entity tag of the object doesn't match")
+	ErrUnknownCompression      = errors.New("compression algorithm is unknown")
+	ErrDecryptionFailed        = errors.New("payload couldn't be decrypted. The payload might be corrupted")
+	ErrInvalidExpiry           = errors.New("expiry of the object must be a RFC 3339 timestamp")
+)
+
+// Lifecycle errors
+var (
+	ErrInvalidLifecycleRule = errors.New("lifecycle rule is invalid")
+)
+
+// Quota errors
+var (
+	ErrQuotaExceeded = errors.New("quota of the owner is exceeded")
+	ErrInvalidQuota  = errors.New("quota is invalid")
+)
+
+// Encryption errors
+var (
+	ErrDataKeyNotFound    = errors.New("data key doesn't exist or has been destroyed")
+	ErrMissingKeyProvider = errors.New("payload is encrypted but no key provider is configured")
+)
+
+// Version errors
+var (
+	ErrVersionNotFound  = errors.New("version of the object doesn't exist")
+	ErrVersionIsCurrent = errors.New("version is the current version of the object")
 )
 
 // HTTP errors
@@ -24,4 +62,15 @@ var (
 var (
 	ErrEmptyQuery          = errors.New("empty query")
 	ErrNameOwnerCtxMissing = errors.New("name is set but missing owner")
+	ErrNilCond             = errors.New("condition of the query is nil")
+	ErrNegativeLimit       = errors.New("limit of the query can't be negative")
+	ErrInvalidCursor       = errors.New("cursor is invalid or doesn't belong to the sorting of the query")
+	ErrInvalidUUID         = errors.New("invalid uuid")
+	ErrUnsortedScan        = errors.New("scan can only be ordered by the id in ascending order")
 )
+
+// nameConflict returns an error wrapping ErrNameConflict
+// for the name of the owner.
+func nameConflict(name, owner string) error {
+	return fmt.Errorf("%w: %s of the owner %s", ErrNameConflict, name, owner)
+}
@@ -2,10 +2,15 @@ package objst
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"mime"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -15,7 +20,13 @@ import (
 )
 
 const (
-	headerContentType = "Content-Type"
+	headerContentType     = "Content-Type"
+	headerContentLength   = "Content-Length"
+	headerETag            = "ETag"
+	headerIfMatch         = "If-Match"
+	headerVary            = "Vary"
+	headerAcceptEncoding  = "Accept-Encoding"
+	headerContentEncoding = "Content-Encoding"
 )
 
 const (
@@ -34,10 +45,41 @@ const (
 )
 
 type objectModel struct {
-	ID       string             `json:"id,omitempty"`
-	Name     string             `json:"name,omitempty"`
-	Owner    string             `json:"owner,omitempty"`
-	Metadata map[MetaKey]string `json:"metadata,omitempty"`
+	ID        string             `json:"id,omitempty"`
+	Name      string             `json:"name,omitempty"`
+	Owner     string             `json:"owner,omitempty"`
+	CreatedAt string             `json:"createdAt,omitempty"`
+	UpdatedAt string             `json:"updatedAt,omitempty"`
+	Size      int64              `json:"size,omitempty"`
+	Checksum  string             `json:"checksum,omitempty"`
+	Version   int                `json:"version,omitempty"`
+	Metadata  map[MetaKey]string `json:"metadata,omitempty"`
+}
+
+func newObjectModel(meta *Metadata) *objectModel {
+	size, _ := strconv.ParseInt(meta.Get(MetaKeySize), 10, 64)
+	return &objectModel{
+		ID:        meta.Get(MetaKeyID),
+		Name:      meta.Get(MetaKeyName),
+		Owner:     meta.Get(MetaKeyOwner),
+		CreatedAt: meta.Get(MetaKeyCreatedAt),
+		UpdatedAt: meta.Get(MetaKeyUpdatedAt),
+		Size:      size,
+		Checksum:  meta.Get(MetaKeyChecksum),
+		Version:   versionOf(meta),
+		Metadata:  meta.UserDefinedPairs(),
+	}
+}
+
+type pageModel struct {
+	Objects []*objectModel `json:"objects"`
+	Cursor  string         `json:"cursor,omitempty"`
+}
+
+type usageModel struct {
+	Owner string `json:"owner"`
+	Usage
+	Quota Quota `json:"quota"`
 }
 
 type HTTPHandler struct {
@@ -48,6 +90,9 @@ type HTTPHandler struct {
 func NewHTTPHandler(bucket *Bucket, opts HTTPHandlerOptions) *HTTPHandler {
 	hl := HTTPHandler{}
 	hl.opts = opts
+	if opts.Timeout == 0 {
+		hl.opts.Timeout = defaultTimeout
+	}
 	if opts.Handler == nil {
 		hl.opts.Handler = hl.routes()
 	}
@@ -65,14 +110,25 @@ func (h *HTTPHandler) routes() chi.Router {
 	r.Use(h.opts.IsAuthenticated)
 	r.Use(requestID)
 	r.Use(middleware.CleanPath)
-	r.Use(middleware.Timeout(defaultTimeout))
+	r.Use(middleware.Timeout(h.opts.Timeout))
 
 	r.Route("/objst", func(r chi.Router) {
 		r.Route("/", func(r chi.Router) {
 			r.Use(h.opts.IsAuthorized)
+			r.With(assureOwner).Get("/", h.List)
+			r.With(assureOwner).Get("/usage", h.Usage)
 			r.Get("/read/{id}", h.Read)
 			r.Get("/{id}", h.Get)
 			r.Delete("/{id}", h.Remove)
+			r.Patch("/{id}", h.Patch)
+			r.Put("/{id}", h.Replace)
+			r.Post("/{id}/rename", h.Rename)
+			r.Post("/{id}/transfer", h.Transfer)
+			r.Post("/{id}/copy", h.Copy)
+			r.Get("/{id}/versions", h.Versions)
+			r.Get("/{id}/versions/{version}", h.GetVersion)
+			r.Post("/{id}/versions/{version}/restore", h.RestoreVersion)
+			r.Delete("/{id}/versions/{version}", h.RemoveVersion)
 		})
 		r.Route("/upload", func(r chi.Router) {
 			r.Use(assureOwner)
@@ -92,44 +148,76 @@ func (h *HTTPHandler) Get(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "id is an invalid uuid-v4", http.StatusBadRequest)
 		return
 	}
-	obj, err := h.bucket.GetByID(id)
+	d, err := h.bucket.Describe(id)
+	if err != nil {
+		h.writeError(w, r, err, "couldn't get the object with the id: "+id)
+		return
+	}
+	w.Header().Set(headerETag, quoteETag(d.ETag()))
+	w.Header().Set(headerContentType, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(d.ToModel()); err != nil {
+		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// List returns a page of the objects of the owner. The page
+// can be controlled using the url query parameters `limit`,
+// `cursor`, `sort` and `order` (asc or desc).
+func (h *HTTPHandler) List(w http.ResponseWriter, r *http.Request) {
+	reqID := r.Context().Value(CtxKeyReqID).(string)
+	owner := r.Context().Value(CtxKeyOwner).(string)
+	q, err := pageQuery(r, NewQuery().Owner(owner))
 	if err != nil {
 		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	page, err := h.bucket.ListContext(r.Context(), q)
+	if err != nil {
+		h.writeError(w, r, err, "something went wrong while fetching the objects")
+		return
+	}
+	res := pageModel{
+		Objects: make([]*objectModel, 0, len(page.Items)),
+		Cursor:  page.Cursor,
+	}
+	for _, d := range page.Items {
+		res.Objects = append(res.Objects, d.ToModel())
+	}
 	w.Header().Set(headerContentType, contentTypeJSON)
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(obj.ToModel()); err != nil {
+	if err := json.NewEncoder(w).Encode(&res); err != nil {
 		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, "something went wrong while sending the objects", http.StatusInternalServerError)
 		return
 	}
 }
 
 func (h *HTTPHandler) Upload(w http.ResponseWriter, r *http.Request) {
 	reqID := r.Context().Value(CtxKeyReqID).(string)
+	r.Body = http.MaxBytesReader(w, r.Body, h.opts.MaxUploadSize)
 	if err := r.ParseMultipartForm(h.opts.MaxUploadSize); err != nil {
+		code := http.StatusBadRequest
+		if statusOf(err) == http.StatusRequestEntityTooLarge {
+			code = http.StatusRequestEntityTooLarge
+		}
 		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
-		http.Error(w, "something went wrong while parsing the multipart form", http.StatusBadRequest)
+		http.Error(w, "something went wrong while parsing the multipart form", code)
 		return
 	}
 	file, header, err := r.FormFile(h.opts.FormKey)
 	if err != nil {
-		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
-		http.Error(w, "couldn't get the file from the multipart form", http.StatusInternalServerError)
+		h.writeError(w, r, err, "couldn't get the file from the multipart form")
 		return
 	}
+	defer file.Close()
 	owner := r.Context().Value(CtxKeyOwner).(string)
 	obj, err := NewObject(header.Filename, owner)
 	if err != nil {
-		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	if _, err := io.Copy(obj, file); err != nil {
-		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
-		http.Error(w, "couldn't copy the payload of the file into the object", http.StatusInternalServerError)
+		h.writeError(w, r, err, "couldn't create the object")
 		return
 	}
 	if obj.GetMetaKey(MetaKeyContentType) == "" {
@@ -146,9 +234,16 @@ func (h *HTTPHandler) Upload(w http.ResponseWriter, r *http.Request) {
 		mime.AddExtensionType(filepath.Ext(header.Filename), contentType)
 		obj.SetMetaKey(MetaKeyContentType, contentType)
 	}
-	if err := h.bucket.Create(obj); err != nil {
+	if err := setFormExpiry(obj, r.Form); err != nil {
 		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
-		http.Error(w, "something went wrong while creating the object", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// stream the file into the bucket without
+	// buffering the payload in the object.
+	err = h.bucket.CreateFromContext(r.Context(), obj, file)
+	if err != nil {
+		h.writeError(w, r, err, "something went wrong while creating the object")
 		return
 	}
 	w.Header().Set(headerContentType, contentTypeJSON)
@@ -160,23 +255,447 @@ func (h *HTTPHandler) Upload(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Usage returns the storage used by the owner and the quota of the owner.
+func (h *HTTPHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	reqID := r.Context().Value(CtxKeyReqID).(string)
+	owner := r.Context().Value(CtxKeyOwner).(string)
+	u, err := h.bucket.Usage(owner)
+	if err != nil {
+		h.writeError(w, r, err, "something went wrong while fetching the usage")
+		return
+	}
+	q, err := h.bucket.Quota(owner)
+	if err != nil {
+		h.writeError(w, r, err, "something went wrong while fetching the quota")
+		return
+	}
+	w.Header().Set(headerContentType, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(&usageModel{Owner: owner, Usage: u, Quota: q}); err != nil {
+		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
+		http.Error(w, "something went wrong while sending the usage", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Read streams the payload of the object. A specific version
+// can be read using the url query parameter `version`.
 func (h *HTTPHandler) Read(w http.ResponseWriter, r *http.Request) {
 	reqID := r.Context().Value(CtxKeyReqID).(string)
 	id := chi.URLParam(r, "id")
-	if err := h.bucket.Read(id, w); err != nil {
+	version := 0
+	if v := r.URL.Query().Get("version"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
+			http.Error(w, "version has to be a number: "+v, http.StatusBadRequest)
+			return
+		}
+		version = n
+	}
+	d, err := h.bucket.Describe(id)
+	if err == nil && version != 0 {
+		d, err = h.bucket.DescribeVersion(id, version)
+	}
+	if err != nil {
+		h.writeError(w, r, err, "couldn't get the object with the id: "+id)
+		return
+	}
+	f, isFile, err := h.bucket.openFile(d.meta)
+	if err != nil {
+		h.writeError(w, r, err, "couldn't read the payload of the object with the id: "+id)
+		return
+	}
+	if isFile {
+		defer f.Close()
+		w.Header().Set(headerETag, quoteETag(d.ETag()))
+		w.Header().Set(headerContentType, d.GetMetaKey(MetaKeyContentType))
+		// serving the file directly supports range requests
+		// and allows the use of sendfile by the server.
+		http.ServeContent(w, r, d.Name(), d.UpdatedAt(), f)
+		return
+	}
+	enc := encodingOf(d.meta)
+	// compressed payloads are served as stored
+	// if the client accepts the compression.
+	isEncoded := enc != CompressionNone && acceptsEncoding(r, enc)
+	var pl io.ReadCloser
+	if isEncoded {
+		pl, err = h.bucket.openStored(d.meta)
+	} else {
+		pl, err = h.bucket.openPayload(d.meta)
+	}
+	if err != nil {
+		h.writeError(w, r, err, "couldn't read the payload of the object with the id: "+id)
+		return
+	}
+	defer pl.Close()
+	w.Header().Set(headerETag, quoteETag(d.ETag()))
+	w.Header().Set(headerContentType, d.GetMetaKey(MetaKeyContentType))
+	if enc != CompressionNone {
+		w.Header().Set(headerVary, headerAcceptEncoding)
+	}
+	if isEncoded {
+		w.Header().Set(headerContentEncoding, string(enc))
+	} else {
+		w.Header().Set(headerContentLength, d.GetMetaKey(MetaKeySize))
+	}
+	// the payload is streamed chunk by chunk so the status
+	// code can't be changed after the streaming has begun.
+	if _, err := io.Copy(w, newCtxReader(r.Context(), pl)); err != nil {
 		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
-		http.Error(w, "something went wrong while streaming the object", http.StatusInternalServerError)
 		return
 	}
 }
 
 func (h *HTTPHandler) Remove(w http.ResponseWriter, r *http.Request) {
-	reqID := r.Context().Value(CtxKeyReqID).(string)
 	id := chi.URLParam(r, "id")
 	if err := h.bucket.DeleteByID(id); err != nil {
+		h.writeError(w, r, err, "couldn't delete the object with the id: "+id)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Patch updates the user defined metadata of the object using
+// the JSON object of the request body. Keys with an empty value
+// will be removed. The update can be made conditional using the
+// `If-Match` header containing the ETag of the object.
+func (h *HTTPHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	reqID := r.Context().Value(CtxKeyReqID).(string)
+	id := chi.URLParam(r, "id")
+	patch := make(map[MetaKey]string)
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
 		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
-		http.Error(w, "couldn't delete the object with the id: "+id, http.StatusBadRequest)
+		http.Error(w, "body has to be a JSON object of meta data", http.StatusBadRequest)
+		return
+	}
+	d, err := h.bucket.UpdateMeta(id, patch, unquoteETag(r.Header.Get(headerIfMatch)))
+	if err != nil {
+		h.writeError(w, r, err, "couldn't update the object with the id: "+id)
+		return
+	}
+	h.writeModel(w, r, d)
+}
+
+// Replace replaces the payload of the object with the request
+// body. The replacement can be made conditional using the
+// `If-Match` header containing the ETag of the object.
+func (h *HTTPHandler) Replace(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	r.Body = http.MaxBytesReader(w, r.Body, h.opts.MaxUploadSize)
+	d, err := h.bucket.Replace(id, r.Body, unquoteETag(r.Header.Get(headerIfMatch)))
+	if err != nil {
+		h.writeError(w, r, err, "couldn't replace the object with the id: "+id)
+		return
+	}
+	h.writeModel(w, r, d)
+}
+
+// Rename changes the name of the object to the
+// `name` of the JSON object of the request body.
+func (h *HTTPHandler) Rename(w http.ResponseWriter, r *http.Request) {
+	reqID := r.Context().Value(CtxKeyReqID).(string)
+	id := chi.URLParam(r, "id")
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
+		http.Error(w, "body has to be a JSON object containing the name", http.StatusBadRequest)
+		return
+	}
+	d, err := h.bucket.Rename(id, body.Name)
+	if err != nil {
+		h.writeError(w, r, err, "couldn't rename the object with the id: "+id)
+		return
+	}
+	h.writeModel(w, r, d)
+}
+
+// Transfer changes the owner of the object to the
+// `owner` of the JSON object of the request body.
+func (h *HTTPHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+	reqID := r.Context().Value(CtxKeyReqID).(string)
+	id := chi.URLParam(r, "id")
+	var body struct {
+		Owner string `json:"owner"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
+		http.Error(w, "body has to be a JSON object containing the owner", http.StatusBadRequest)
+		return
+	}
+	d, err := h.bucket.Transfer(id, body.Owner)
+	if err != nil {
+		h.writeError(w, r, err, "couldn't transfer the object with the id: "+id)
+		return
+	}
+	h.writeModel(w, r, d)
+}
+
+// Copy creates a copy of the object using the `name`, `owner`
+// and `metadata` of the JSON object of the request body. The
+// owner of the source object is used if no owner is provided.
+func (h *HTTPHandler) Copy(w http.ResponseWriter, r *http.Request) {
+	reqID := r.Context().Value(CtxKeyReqID).(string)
+	id := chi.URLParam(r, "id")
+	var body struct {
+		Name     string             `json:"name"`
+		Owner    string             `json:"owner"`
+		Metadata map[MetaKey]string `json:"metadata"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
+		http.Error(w, "body has to be a JSON object containing the name", http.StatusBadRequest)
+		return
+	}
+	d, err := h.bucket.Copy(id, body.Name, body.Owner, body.Metadata)
+	if err != nil {
+		h.writeError(w, r, err, "couldn't copy the object with the id: "+id)
+		return
+	}
+	h.writeModel(w, r, d)
+}
+
+// writeModel sends the model and the ETag of the object.
+func (h *HTTPHandler) writeModel(w http.ResponseWriter, r *http.Request, d *Descriptor) {
+	reqID := r.Context().Value(CtxKeyReqID).(string)
+	w.Header().Set(headerETag, quoteETag(d.ETag()))
+	w.Header().Set(headerContentType, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(d.ToModel()); err != nil {
+		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
+		http.Error(w, "something went wrong while sending the object model", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Versions returns the models of all versions of the
+// object ordered from the oldest to the current version.
+func (h *HTTPHandler) Versions(w http.ResponseWriter, r *http.Request) {
+	reqID := r.Context().Value(CtxKeyReqID).(string)
+	id := chi.URLParam(r, "id")
+	ds, err := h.bucket.Versions(id)
+	if err != nil {
+		h.writeError(w, r, err, "couldn't list the versions of the object with the id: "+id)
+		return
+	}
+	res := make([]*objectModel, 0, len(ds))
+	for _, d := range ds {
+		res = append(res, d.ToModel())
+	}
+	w.Header().Set(headerContentType, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
+		http.Error(w, "something went wrong while sending the versions", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetVersion returns the model of the given version of the object.
+func (h *HTTPHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	reqID := r.Context().Value(CtxKeyReqID).(string)
+	id := chi.URLParam(r, "id")
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
+		http.Error(w, "version has to be a number", http.StatusBadRequest)
+		return
+	}
+	d, err := h.bucket.DescribeVersion(id, version)
+	if err != nil {
+		h.writeError(w, r, err, "couldn't get the version of the object with the id: "+id)
+		return
+	}
+	w.Header().Set(headerContentType, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(d.ToModel()); err != nil {
+		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// RestoreVersion makes the given version the current version
+// of the object and returns the model of the new version.
+func (h *HTTPHandler) RestoreVersion(w http.ResponseWriter, r *http.Request) {
+	reqID := r.Context().Value(CtxKeyReqID).(string)
+	id := chi.URLParam(r, "id")
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
+		http.Error(w, "version has to be a number", http.StatusBadRequest)
+		return
+	}
+	d, err := h.bucket.RestoreVersion(id, version)
+	if err != nil {
+		h.writeError(w, r, err, "couldn't restore the version of the object with the id: "+id)
+		return
+	}
+	w.Header().Set(headerContentType, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(d.ToModel()); err != nil {
+		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// RemoveVersion deletes a noncurrent version of the object.
+func (h *HTTPHandler) RemoveVersion(w http.ResponseWriter, r *http.Request) {
+	reqID := r.Context().Value(CtxKeyReqID).(string)
+	id := chi.URLParam(r, "id")
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
+		http.Error(w, "version has to be a number", http.StatusBadRequest)
+		return
+	}
+	if err := h.bucket.DeleteVersion(id, version); err != nil {
+		h.writeError(w, r, err, "couldn't delete the version of the object with the id: "+id)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// clientErrs are the errors caused by an invalid request.
+var clientErrs = []error{
+	ErrInvalidNamePattern,
+	ErrContentTypeNotExist,
+	ErrEmptyPayload,
+	ErrObjectIsImmutable,
+	ErrMustIncludeOwnerAndName,
+	ErrUnknownCompression,
+	ErrInvalidExpiry,
+	ErrInvalidLifecycleRule,
+	ErrInvalidQuota,
+	ErrMissingOwner,
+	ErrUknownContentType,
+	ErrEmptyQuery,
+	ErrNameOwnerCtxMissing,
+	ErrNilCond,
+	ErrNegativeLimit,
+	ErrInvalidCursor,
+	ErrInvalidUUID,
+	ErrUnsortedScan,
+	http.ErrMissingFile,
+}
+
+// statusOf returns the status code of the response to
+// the error. Unknown errors are internal server errors.
+func statusOf(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, ErrObjectNotFound), errors.Is(err, ErrVersionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrNameConflict), errors.Is(err, ErrVersionIsCurrent):
+		return http.StatusConflict
+	case errors.Is(err, ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, ErrQuotaExceeded):
+		return http.StatusInsufficientStorage
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	}
+	for _, target := range clientErrs {
+		if errors.Is(err, target) {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// writeError logs the error and responds with the status code of
+// the error. Internal server errors respond with msg instead of
+// the error to not expose any details of the storage.
+func (h *HTTPHandler) writeError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	reqID := r.Context().Value(CtxKeyReqID).(string)
+	h.opts.Logger.ErrorCtx(r.Context(), err.Error(), slog.String("req_id", reqID))
+	if r.Context().Err() != nil {
+		// the client is gone or the timeout
+		// middleware responds to the request.
+		return
+	}
+	code := statusOf(err)
+	if code != http.StatusInternalServerError {
+		msg = err.Error()
+	}
+	http.Error(w, msg, code)
+}
+
+// pageQuery sets the paging parameters of the
+// request's url query on the given query.
+func pageQuery(r *http.Request, q *Query) (*Query, error) {
+	params := r.URL.Query()
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("limit has to be a positive number: %s", limit)
+		}
+		q.Limit(n)
+	}
+	o := Asc
+	switch params.Get("order") {
+	case "", "asc":
+	case "desc":
+		o = Desc
+	default:
+		return nil, fmt.Errorf("order has to be asc or desc: %s", params.Get("order"))
+	}
+	q.SortBy(MetaKey(params.Get("sort")), o)
+	q.Cursor(params.Get("cursor"))
+	return q, nil
+}
+
+func quoteETag(etag string) string {
+	return strconv.Quote(etag)
+}
+
+// unquoteETag returns the entity tag of an If-Match
+// header. Weak tags are treated like strong tags.
+func unquoteETag(header string) string {
+	header = strings.TrimPrefix(header, "W/")
+	if etag, err := strconv.Unquote(header); err == nil {
+		return etag
+	}
+	return header
+}
+
+// acceptsEncoding reports if the client accepts
+// the compression as the content encoding.
+func acceptsEncoding(r *http.Request, enc Compression) bool {
+	for _, v := range r.Header.Values(headerAcceptEncoding) {
+		for _, part := range strings.Split(v, ",") {
+			coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			if strings.TrimSpace(coding) != string(enc) {
+				continue
+			}
+			q := strings.ReplaceAll(params, " ", "")
+			return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+		}
+	}
+	return false
+}
+
+// setFormExpiry sets the expiry of the object using the form field
+// `expiresAt` containing a RFC 3339 timestamp or the form field `ttl`
+// containing a duration e.g. `24h` relative to the current time.
+func setFormExpiry(obj *Object, form url.Values) error {
+	if v := form.Get(MetaKeyExpiresAt.String()); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidExpiry, v)
+		}
+		obj.SetExpiry(t)
+	}
+	if v := form.Get("ttl"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("%w: ttl must be a positive duration: %s", ErrInvalidExpiry, v)
+		}
+		obj.SetExpiry(time.Now().Add(ttl))
+	}
+	return nil
+}
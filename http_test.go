@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -221,3 +222,301 @@ func TestHTTPUploadUknownMimeTypeAndEmptyCt(t *testing.T) {
 		t.Fatalf("statuscode is not as expected. Got: %d. Expected: %d", w.Code, http.StatusOK)
 	}
 }
+
+func TestHTTPUploadInvalid(t *testing.T) {
+	target, err := url.JoinPath(tEnv.ts.URL, route, "upload")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	tests := []struct {
+		name    string
+		formKey string
+		owner   string
+	}{
+		{
+			name:    "missing file",
+			formKey: "unknown",
+			owner:   uuid.NewString(),
+		},
+		{
+			name:    "missing owner",
+			formKey: tEnv.h.opts.FormKey,
+			owner:   "",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := tEnv.newUploadRequest(target, nil, tc.formKey, "testdata/images/2500KB.jpg")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			// the handler is called directly because the
+			// router rejects requests without an owner.
+			ctx := context.WithValue(r.Context(), CtxKeyOwner, tc.owner)
+			ctx = context.WithValue(ctx, CtxKeyReqID, uuid.NewString())
+			w := httptest.NewRecorder()
+			tEnv.h.Upload(w, r.WithContext(ctx))
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("statuscode is not %d. Got: %d. Res: %v", http.StatusBadRequest, w.Code, w.Body)
+			}
+		})
+	}
+}
+
+func TestHTTPList(t *testing.T) {
+	owner := tEnv.owner()
+	for i := 0; i < 3; i++ {
+		o := tEnv.obj()
+		o.meta.set(MetaKeyOwner, owner)
+		if err := tEnv.b.Create(o); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	injectOwner := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), CtxKeyOwner, owner)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+	hl := injectOwner(tEnv.h)
+	cursor := ""
+	objects := 0
+	for i := 0; i < 2; i++ {
+		target, err := url.JoinPath(tEnv.ts.URL, route)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		r := httptest.NewRequest(http.MethodGet, target+"?limit=2&cursor="+cursor, nil)
+		w := httptest.NewRecorder()
+		hl.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("statuscode is not %d. Got: %d. Res: %v", http.StatusOK, w.Code, w.Body)
+		}
+		m := pageModel{}
+		if err := json.NewDecoder(w.Body).Decode(&m); err != nil {
+			t.Error(err)
+			return
+		}
+		objects += len(m.Objects)
+		cursor = m.Cursor
+	}
+	if objects != 3 || cursor != "" {
+		t.Fatalf("pages are not as expected. Got: %d objects and cursor %q", objects, cursor)
+	}
+}
+
+func TestHTTPVersions(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	if err := b.SetVersioning(true); err != nil {
+		t.Error(err)
+		return
+	}
+	ts := httptest.NewServer(NewHTTPHandler(b, DefaultHTTPHandlerOptions()))
+	defer ts.Close()
+	id, pls := createVersions(t, b, tEnv.name(), tEnv.owner(), 2)
+	target, err := url.JoinPath(ts.URL, route, id, "versions")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	res, err := ts.Client().Get(target)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer res.Body.Close()
+	var models []*objectModel
+	if err := json.NewDecoder(res.Body).Decode(&models); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(models) != 2 || models[0].Version != 1 || models[1].Version != 2 {
+		t.Fatalf("versions are not listed correctly. Got: %+v", models)
+	}
+	target, err = url.JoinPath(ts.URL, route, "read", id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	res, err = ts.Client().Get(target + "?version=1")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer res.Body.Close()
+	pl, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(pl, pls[0]) {
+		t.Fatalf("payload of the first version is not correct. Got: %s. Expected: %s", pl, pls[0])
+	}
+	target, err = url.JoinPath(ts.URL, route, id, "versions", "1", "restore")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	res, err = ts.Client().Post(target, contentTypeJSON, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("restore should succeed. Got: %d", res.StatusCode)
+	}
+}
+
+func TestHTTPPatch(t *testing.T) {
+	o := tEnv.obj()
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	target, err := url.JoinPath(tEnv.ts.URL, route, o.ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	tests := []struct {
+		name    string
+		ifMatch string
+		code    int
+	}{
+		{
+			name:    "stale etag",
+			ifMatch: `"stale"`,
+			code:    http.StatusPreconditionFailed,
+		},
+		{
+			name: "unconditional",
+			code: http.StatusOK,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := http.NewRequest(http.MethodPatch, target, bytes.NewBufferString(`{"foo": "bar"}`))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if tc.ifMatch != "" {
+				r.Header.Set(headerIfMatch, tc.ifMatch)
+			}
+			res, err := tEnv.ts.Client().Do(r)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer res.Body.Close()
+			if res.StatusCode != tc.code {
+				t.Fatalf("status code is not correct. Got: %d. Expected: %d", res.StatusCode, tc.code)
+			}
+		})
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	o := tEnv.obj()
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	tests := []struct {
+		name   string
+		method string
+		path   []string
+		body   string
+		code   int
+	}{
+		{
+			name:   "object not found",
+			method: http.MethodGet,
+			path:   []string{uuid.NewString()},
+			code:   http.StatusNotFound,
+		},
+		{
+			name:   "version not found",
+			method: http.MethodGet,
+			path:   []string{o.ID(), "versions", "5"},
+			code:   http.StatusNotFound,
+		},
+		{
+			name:   "delete object not found",
+			method: http.MethodDelete,
+			path:   []string{uuid.NewString()},
+			code:   http.StatusNotFound,
+		},
+		{
+			name:   "delete current version",
+			method: http.MethodDelete,
+			path:   []string{o.ID(), "versions", "1"},
+			code:   http.StatusConflict,
+		},
+		{
+			name:   "name conflict",
+			method: http.MethodPost,
+			path:   []string{o.ID(), "copy"},
+			body:   fmt.Sprintf(`{"name": %q}`, o.Name()),
+			code:   http.StatusConflict,
+		},
+		{
+			name:   "invalid name",
+			method: http.MethodPost,
+			path:   []string{o.ID(), "rename"},
+			body:   `{"name": ""}`,
+			code:   http.StatusBadRequest,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			target, err := url.JoinPath(tEnv.ts.URL, append([]string{route}, tc.path...)...)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			r, err := http.NewRequest(tc.method, target, bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			res, err := tEnv.ts.Client().Do(r)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer res.Body.Close()
+			if res.StatusCode != tc.code {
+				t.Fatalf("status code is not correct. Got: %d. Expected: %d", res.StatusCode, tc.code)
+			}
+		})
+	}
+}
+
+func TestHTTPUploadTooLarge(t *testing.T) {
+	opts := DefaultHTTPHandlerOptions()
+	opts.MaxUploadSize = 1 << 10
+	h := NewHTTPHandler(tEnv.b, opts)
+	target, err := url.JoinPath(tEnv.ts.URL, route, "upload")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	r, err := tEnv.newUploadRequest(target, nil, opts.FormKey, "testdata/images/2500KB.jpg")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	r = r.WithContext(context.WithValue(r.Context(), CtxKeyOwner, uuid.NewString()))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("statuscode is not %d. Got: %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+}
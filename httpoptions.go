@@ -3,6 +3,7 @@ package objst
 import (
 	"net/http"
 	"os"
+	"time"
 
 	"golang.org/x/exp/slog"
 )
@@ -34,6 +35,11 @@ type HTTPHandlerOptions struct {
 	// by the default handler.
 	Handler http.Handler
 
+	// Timeout is the maximum duration of a request. The
+	// bucket operations of a request are cancelled once
+	// the timeout is exceeded. Default: 5s.
+	Timeout time.Duration
+
 	// Logger is the default logger. By default slog.Logger
 	// with the text handler will be used.
 	Logger *slog.Logger
@@ -48,6 +54,7 @@ func DefaultHTTPHandlerOptions() HTTPHandlerOptions {
 
 	opts.MaxUploadSize = mib32
 	opts.FormKey = formKey
+	opts.Timeout = defaultTimeout
 	opts.IsAuthorized = isAuthorized
 	opts.IsAuthenticated = isAuthenticated
 	opts.Handler = nil
@@ -0,0 +1,386 @@
+package objst
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// prefixIndex is the prefix of the index entries
+	// in the format idx/<key>\x00<value>\x00<id>.
+	prefixIndex = "idx/"
+	// prefixIndexDef is the prefix of the persisted
+	// definitions of user created indexes.
+	prefixIndexDef = "sys/index/"
+	indexSep       = "\x00"
+	// backfillSize is the maximum number of objects
+	// indexed in one transaction of a backfill.
+	backfillSize = 1000
+)
+
+// defaultIndexes are the meta keys which
+// are indexed for every bucket.
+var defaultIndexes = []MetaKey{MetaKeyOwner, MetaKeyName, MetaKeyContentType}
+
+func indexKey(k MetaKey, v, id string) []byte {
+	return []byte(prefixIndex + k.String() + indexSep + v + indexSep + id)
+}
+
+func indexDefKey(k MetaKey) []byte {
+	return []byte(prefixIndexDef + k.String())
+}
+
+// parseIndexKey returns the value and id of the index entry.
+func parseIndexKey(k MetaKey, key []byte) (string, string) {
+	entry := strings.TrimPrefix(string(key), prefixIndex+k.String()+indexSep)
+	i := strings.LastIndex(entry, indexSep)
+	return entry[:i], entry[i+1:]
+}
+
+// indexSet is the set of indexed meta keys shared
+// between all copies of a bucket.
+type indexSet struct {
+	mu   sync.RWMutex
+	keys []MetaKey
+}
+
+func newIndexSet() *indexSet {
+	return &indexSet{
+		keys: slices.Clone(defaultIndexes),
+	}
+}
+
+func (s *indexSet) has(k MetaKey) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Contains(s.keys, k)
+}
+
+// add adds the key to the set and reports
+// if the key wasn't part of the set before.
+func (s *indexSet) add(k MetaKey) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slices.Contains(s.keys, k) {
+		return false
+	}
+	s.keys = append(s.keys, k)
+	return true
+}
+
+func (s *indexSet) remove(k MetaKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := slices.Index(s.keys, k); i >= 0 {
+		s.keys = slices.Delete(s.keys, i, i+1)
+	}
+}
+
+func (s *indexSet) list() []MetaKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.keys)
+}
+
+// CreateIndex creates an index for the given meta key. Queries
+// using the meta key will use the index instead of scanning all
+// objects. The index is persisted and will be kept up to date
+// for all existing and future objects.
+func (b Bucket) CreateIndex(k MetaKey) error {
+	// the key is added first to index the objects
+	// which are modified during the backfill.
+	if k == MetaKeyID || !b.indexes.add(k) {
+		return nil
+	}
+	err := b.meta.Update(func(txn storeTxn) error {
+		return txn.Set(indexDefKey(k), nil)
+	})
+	if err == nil {
+		err = b.backfillIndex(k)
+	}
+	if err != nil {
+		b.indexes.remove(k)
+		return errors.Join(err, b.meta.Update(func(txn storeTxn) error {
+			return txn.Delete(indexDefKey(k))
+		}))
+	}
+	return nil
+}
+
+// Indexes returns all indexed meta keys of the bucket.
+func (b Bucket) Indexes() []MetaKey {
+	return b.indexes.list()
+}
+
+// loadIndexes loads the persisted index definitions.
+func (b Bucket) loadIndexes() error {
+	return b.meta.View(func(txn storeTxn) error {
+		it := txn.Iterate([]byte(prefixIndexDef), true)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			b.indexes.add(MetaKey(strings.TrimPrefix(string(it.Key()), prefixIndexDef)))
+		}
+		return nil
+	})
+}
+
+// backfillIndex creates the index entries of the given key for
+// all existing objects. The objects are indexed in bounded
+// transactions which read the metadata they index. A transaction
+// conflicting with a concurrent modification is retried to not
+// leave entries of outdated metadata behind.
+func (b Bucket) backfillIndex(k MetaKey) error {
+	start, done := "", false
+	for !done {
+		var last string
+		err := b.meta.Update(func(txn storeTxn) error {
+			ids := newKeyStream(txn, prefixMeta, start, Asc)
+			defer ids.close()
+			last, done = start, true
+			for n := 0; n < backfillSize; n++ {
+				id, ok := ids.next()
+				if !ok {
+					return nil
+				}
+				meta, err := b.getMeta(txn, id)
+				if err != nil {
+					return err
+				}
+				last = id
+				if !meta.Has(k) {
+					continue
+				}
+				if err := txn.Set(indexKey(k, meta.Get(k), id), nil); err != nil {
+					return err
+				}
+			}
+			done = false
+			return nil
+		})
+		if errors.Is(err, errConflict) {
+			done = false
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		start = last
+	}
+	return nil
+}
+
+// setIndexes inserts the index, sort and expiry entries of the object.
+func (b Bucket) setIndexes(txn storeTxn, meta *Metadata) error {
+	id := meta.Get(MetaKeyID)
+	for _, k := range b.indexes.list() {
+		if !meta.Has(k) {
+			continue
+		}
+		if err := txn.Set(indexKey(k, meta.Get(k), id), nil); err != nil {
+			return err
+		}
+	}
+	if err := setSortEntries(txn, meta); err != nil {
+		return err
+	}
+	return setExpiry(txn, meta)
+}
+
+// deleteIndexes removes the index, sort and expiry entries of the object.
+func (b Bucket) deleteIndexes(txn storeTxn, meta *Metadata) error {
+	id := meta.Get(MetaKeyID)
+	for _, k := range b.indexes.list() {
+		if !meta.Has(k) {
+			continue
+		}
+		if err := txn.Delete(indexKey(k, meta.Get(k), id)); err != nil {
+			return err
+		}
+	}
+	if err := deleteSortEntries(txn, meta); err != nil {
+		return err
+	}
+	return deleteExpiry(txn, meta)
+}
+
+// lookupIndex returns the ids of all objects whose value of the
+// indexed key k starts with prefix and fulfills the filter. If
+// exact is set only the values equal to prefix are visited.
+func (b Bucket) lookupIndex(txn storeTxn, k MetaKey, prefix string, exact bool, filter func(v string) bool) []string {
+	seek := []byte(prefixIndex + k.String() + indexSep + prefix)
+	if exact {
+		seek = append(seek, indexSep...)
+	}
+	it := txn.Iterate(seek, true)
+	defer it.Close()
+	ids := make([]string, 0)
+	for it.Rewind(); it.Valid(); it.Next() {
+		v, id := parseIndexKey(k, it.Key())
+		if filter == nil || filter(v) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// lookupValue returns the ids of all objects whose value of
+// k is equal to v. The returned bool reports if k is indexed.
+func (b Bucket) lookupValue(txn storeTxn, k MetaKey, v string) ([]string, bool, error) {
+	if k != MetaKeyID {
+		if !b.indexes.has(k) {
+			return nil, false, nil
+		}
+		return b.lookupIndex(txn, k, v, true, nil), true, nil
+	}
+	_, err := txn.Get(metaKey(v))
+	if errors.Is(err, errKeyNotFound) {
+		return []string{}, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return []string{v}, true, nil
+}
+
+// Ranks of the expected cost to find
+// the candidates of a condition.
+const (
+	rankID = iota
+	rankExact
+	rankPrefix
+	rankRange
+)
+
+// rank returns the expected cost of finding the candidates of the
+// condition using the indexes of the bucket. The returned bool
+// reports if the indexes are sufficient to find the candidates.
+func (b Bucket) rank(c Cond) (int, bool) {
+	switch c := c.(type) {
+	case eqCond:
+		return b.rankKey(c.k, rankExact)
+	case inCond:
+		return b.rankKey(c.k, rankExact)
+	case prefixCond:
+		return b.rankKey(c.k, rankPrefix)
+	case matchCond:
+		literal, complete := c.re.LiteralPrefix()
+		if complete {
+			return b.rankKey(c.k, rankExact)
+		}
+		if literal != "" {
+			return b.rankKey(c.k, rankPrefix)
+		}
+		return b.rankKey(c.k, rankRange)
+	case allCond:
+		// the cheapest condition is sufficient
+		best, ok := 0, false
+		for _, cond := range c.conds {
+			r, condOk := b.rank(cond)
+			if condOk && (!ok || r < best) {
+				best, ok = r, true
+			}
+		}
+		return best, ok
+	case anyCond:
+		// every condition has to be looked up
+		worst := 0
+		for _, cond := range c.conds {
+			r, ok := b.rank(cond)
+			if !ok {
+				return 0, false
+			}
+			if r > worst {
+				worst = r
+			}
+		}
+		return worst, len(c.conds) > 0
+	}
+	return 0, false
+}
+
+func (b Bucket) rankKey(k MetaKey, r int) (int, bool) {
+	if k == MetaKeyID && r == rankExact {
+		return rankID, true
+	}
+	return r, b.indexes.has(k)
+}
+
+// candidates returns the ids of all objects which might fulfill
+// the condition using the indexes of the bucket. The returned bool
+// reports if the indexes were sufficient to find the candidates.
+// Otherwise all objects have to be scanned.
+func (b Bucket) candidates(txn storeTxn, c Cond) ([]string, bool, error) {
+	if _, ok := b.rank(c); !ok {
+		return nil, false, nil
+	}
+	switch c := c.(type) {
+	case eqCond:
+		return b.lookupValue(txn, c.k, c.v)
+	case inCond:
+		ids := newIDSet()
+		for _, v := range c.vs {
+			res, _, err := b.lookupValue(txn, c.k, v)
+			if err != nil {
+				return nil, false, err
+			}
+			ids.add(res...)
+		}
+		return ids.list, true, nil
+	case prefixCond:
+		return b.lookupIndex(txn, c.k, c.prefix, false, nil), true, nil
+	case matchCond:
+		literal, complete := c.re.LiteralPrefix()
+		if c.k == MetaKeyID {
+			return b.lookupValue(txn, c.k, literal)
+		}
+		return b.lookupIndex(txn, c.k, literal, complete, c.re.MatchString), true, nil
+	case allCond:
+		// the candidates of one condition are sufficient
+		// because every object has to fulfill all of them.
+		var best Cond
+		bestRank := 0
+		for _, cond := range c.conds {
+			r, ok := b.rank(cond)
+			if ok && (best == nil || r < bestRank) {
+				best, bestRank = cond, r
+			}
+		}
+		return b.candidates(txn, best)
+	case anyCond:
+		ids := newIDSet()
+		for _, cond := range c.conds {
+			res, _, err := b.candidates(txn, cond)
+			if err != nil {
+				return nil, false, err
+			}
+			ids.add(res...)
+		}
+		return ids.list, true, nil
+	}
+	return nil, false, nil
+}
+
+// idSet is an ordered set of ids.
+type idSet struct {
+	seen map[string]bool
+	list []string
+}
+
+func newIDSet() *idSet {
+	return &idSet{
+		seen: make(map[string]bool),
+		list: make([]string, 0),
+	}
+}
+
+func (s *idSet) add(ids ...string) {
+	for _, id := range ids {
+		if !s.seen[id] {
+			s.seen[id] = true
+			s.list = append(s.list, id)
+		}
+	}
+}
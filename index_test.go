@@ -0,0 +1,181 @@
+package objst
+
+import (
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestCreateIndex(t *testing.T) {
+	const (
+		color MetaKey = "color"
+		blue  string  = "blue"
+	)
+	// objects created before the index
+	// exists have to be backfilled.
+	before := tEnv.obj()
+	before.SetMetaKey(color, blue)
+	if err := tEnv.b.Create(before); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := tEnv.b.CreateIndex(color); err != nil {
+		t.Error(err)
+		return
+	}
+	after := tEnv.obj()
+	after.SetMetaKey(color, blue)
+	if err := tEnv.b.Create(after); err != nil {
+		t.Error(err)
+		return
+	}
+	q := NewQuery().Param(color, blue)
+	err := tEnv.b.meta.View(func(txn storeTxn) error {
+		ids, ok, err := tEnv.b.candidates(txn, q.condition())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			t.Fatalf("query should be answered by the index")
+		}
+		if !slices.Contains(ids, before.ID()) || !slices.Contains(ids, after.ID()) {
+			t.Fatalf("index is missing objects. Got: %v", ids)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCandidatesFallback(t *testing.T) {
+	tests := []struct {
+		name string
+		q    *Query
+		ok   bool
+	}{
+		{
+			name: "exact owner",
+			q:    NewQuery().Owner(tEnv.owner()),
+			ok:   true,
+		},
+		{
+			name: "and with one unindexed param",
+			q:    NewQuery().Owner(tEnv.owner()).Param("unindexed", "value").Action(And),
+			ok:   true,
+		},
+		{
+			name: "or with one unindexed param",
+			q:    NewQuery().Owner(tEnv.owner()).Param("unindexed", "value"),
+			ok:   false,
+		},
+		{
+			name: "in and prefix conditions",
+			q:    NewQuery().Where(Any(In(MetaKeyOwner, tEnv.owner(), tEnv.owner()), Prefix(MetaKeyName, "obj_"))),
+			ok:   true,
+		},
+		{
+			name: "negated condition",
+			q:    NewQuery().Where(Not(Eq(MetaKeyOwner, tEnv.owner()))),
+			ok:   false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := tEnv.b.meta.View(func(txn storeTxn) error {
+				_, ok, err := tEnv.b.candidates(txn, test.q.condition())
+				if ok != test.ok {
+					t.Fatalf("index usage is not as expected. Got: %t. Expected: %t", ok, test.ok)
+				}
+				return err
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestIndexAfterDelete(t *testing.T) {
+	o := tEnv.obj()
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := tEnv.b.DeleteByID(o.ID()); err != nil {
+		t.Error(err)
+		return
+	}
+	err := tEnv.b.meta.View(func(txn storeTxn) error {
+		ids := tEnv.b.lookupIndex(txn, MetaKeyOwner, o.Owner(), true, nil)
+		if len(ids) != 0 {
+			t.Fatalf("index entries should be removed. Got: %v", ids)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIndexesAfterReopen(t *testing.T) {
+	const color MetaKey = "color"
+	b, dir := newTempBucket(t, nil)
+	if err := b.CreateIndex(color); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := b.Shutdown(); err != nil {
+		t.Error(err)
+		return
+	}
+	b = reopenTempBucket(t, dir, nil)
+	defer b.Shutdown()
+	if !slices.Contains(b.Indexes(), color) {
+		t.Fatalf("index definition should be persisted. Got: %v", b.Indexes())
+	}
+}
+
+func TestCreateIndexBackfill(t *testing.T) {
+	const color MetaKey = "color"
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	// more objects than fit into one backfill transaction
+	objs := tEnv.nObj(backfillSize + 1)
+	for _, o := range objs {
+		o.SetMetaKey(color, "blue")
+	}
+	if err := b.BatchCreate(objs); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := b.CreateIndex(color); err != nil {
+		t.Error(err)
+		return
+	}
+	err := b.meta.View(func(txn storeTxn) error {
+		ids := b.lookupIndex(txn, color, "blue", true, nil)
+		if len(ids) != len(objs) {
+			t.Fatalf("all objects should be indexed. Got: %d of %d", len(ids), len(objs))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateIndexFailure(t *testing.T) {
+	const color MetaKey = "color"
+	b, _ := newTempBucket(t, nil)
+	if err := b.Shutdown(); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := b.CreateIndex(color); err == nil {
+		t.Fatalf("index should not be created on a closed bucket")
+	}
+	if slices.Contains(b.Indexes(), color) {
+		t.Fatalf("failed index should be removed. Got: %v", b.Indexes())
+	}
+}
@@ -0,0 +1,76 @@
+package objst
+
+import (
+	"errors"
+	"strings"
+)
+
+// insertIntents records a write intent for every given payload.
+// The intents have to be persisted before the payload is
+// written to be able to remove orphaned payloads.
+func (b Bucket) insertIntents(blobs ...string) error {
+	wb := b.meta.NewBatch()
+	defer wb.Cancel()
+	for _, blob := range blobs {
+		if err := wb.Set(intentKey(blob), nil); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+// resolveIntent removes the payload with the given id and the
+// intent itself. An intent is removed in the same transaction in
+// which the metadata referencing the payload is committed, so an
+// existing intent always marks an orphaned payload.
+func (b Bucket) resolveIntent(blob string) error {
+	if err := b.blobs.Delete(blob); err != nil {
+		return err
+	}
+	return b.deleteIntent(blob)
+}
+
+// resolveIntents resolves the intents of all given payloads.
+func (b Bucket) resolveIntents(blobs ...string) error {
+	for _, blob := range blobs {
+		if err := b.resolveIntent(blob); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollback removes the partially inserted payloads with
+// the given ids and returns the cause of the rollback. If
+// the rollback fails the intents will be resolved the next
+// time the bucket is opened.
+func (b Bucket) rollback(cause error, blobs ...string) error {
+	if err := b.resolveIntents(blobs...); err != nil {
+		return errors.Join(cause, err)
+	}
+	return cause
+}
+
+// replayIntents resolves all intents which were left
+// behind e.g. because of a crash of the application.
+func (b Bucket) replayIntents() error {
+	ids := make([]string, 0)
+	err := b.meta.View(func(txn storeTxn) error {
+		it := txn.Iterate([]byte(prefixIntent), true)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			ids = append(ids, strings.TrimPrefix(string(it.Key()), prefixIntent))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return b.resolveIntents(ids...)
+}
+
+func (b Bucket) deleteIntent(blob string) error {
+	return b.meta.Update(func(txn storeTxn) error {
+		return txn.Delete(intentKey(blob))
+	})
+}
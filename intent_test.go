@@ -0,0 +1,63 @@
+package objst
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestReplayIntents(t *testing.T) {
+	b, dir := newTempBucket(t, nil)
+	// simulate a crash after the payload was written
+	// but before the metadata could be committed.
+	o := tEnv.obj()
+	if err := b.insertIntents(o.ID()); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := b.insertPayload(o.ID(), bytes.NewReader(o.Payload()), payloadFormat{}); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := b.Shutdown(); err != nil {
+		t.Error(err)
+		return
+	}
+	b = reopenTempBucket(t, dir, nil)
+	defer b.Shutdown()
+	if _, err := b.blobs.Open(o.ID()); !errors.Is(err, errKeyNotFound) {
+		t.Fatalf("orphaned payload should be removed on open. Got: %v", err)
+	}
+}
+
+func TestRollbackOnNameConflict(t *testing.T) {
+	o1 := tEnv.obj()
+	if err := tEnv.b.Create(o1); err != nil {
+		t.Error(err)
+		return
+	}
+	o2 := tEnv.obj()
+	o2.meta.set(MetaKeyName, o1.Name())
+	o2.meta.set(MetaKeyOwner, o1.Owner())
+	if err := tEnv.b.insertIntents(o2.ID()); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := tEnv.b.insertPayload(o2.ID(), bytes.NewReader(o2.Payload()), payloadFormat{}); err != nil {
+		t.Error(err)
+		return
+	}
+	err := tEnv.b.meta.Update(func(txn storeTxn) error {
+		_, err := tEnv.b.commitObject(txn, o2.meta)
+		return err
+	})
+	if err == nil {
+		t.Fatalf("commit should detect the existing name")
+	}
+	if err := tEnv.b.rollback(err, o2.ID()); err == nil {
+		t.Fatalf("rollback should return the cause")
+	}
+	if _, err := tEnv.b.blobs.Open(o2.ID()); !errors.Is(err, errKeyNotFound) {
+		t.Fatalf("payload should be removed after the rollback. Got: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package objst
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// dataKeySize is the size of a data key in bytes.
+const dataKeySize = 32
+
+// KeyProvider provides the data keys used for the envelope
+// encryption of the payloads. Every owner has its own data
+// keys. Destroying the keys of an owner (crypto-shredding)
+// makes all payloads of the owner unreadable.
+type KeyProvider interface {
+	// DataKey returns the id and the 32 byte data key used
+	// to encrypt new payloads of the owner. A new key should
+	// be created if the owner doesn't have a key yet.
+	DataKey(owner string) (string, []byte, error)
+
+	// Key returns the data key with the given id. It has to
+	// return an error wrapping ErrDataKeyNotFound if the key
+	// has been destroyed.
+	Key(id string) ([]byte, error)
+}
+
+// MemoryKeyProvider is a KeyProvider keeping the data keys in
+// memory. It's intended for tests and ephemeral buckets because
+// the keys are lost when the application stops.
+type MemoryKeyProvider struct {
+	mu sync.Mutex
+	// current is the id of the current key of an owner
+	current map[string]string
+	keys    map[string][]byte
+	owners  map[string]string
+}
+
+func NewMemoryKeyProvider() *MemoryKeyProvider {
+	return &MemoryKeyProvider{
+		current: make(map[string]string),
+		keys:    make(map[string][]byte),
+		owners:  make(map[string]string),
+	}
+}
+
+func (m *MemoryKeyProvider) DataKey(owner string) (string, []byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id, ok := m.current[owner]; ok {
+		return id, m.keys[id], nil
+	}
+	key := make([]byte, dataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", nil, err
+	}
+	id := uuid.NewString()
+	m.current[owner] = id
+	m.keys[id] = key
+	m.owners[id] = owner
+	return id, key, nil
+}
+
+func (m *MemoryKeyProvider) Key(id string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, ok := m.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrDataKeyNotFound, id)
+	}
+	return key, nil
+}
+
+// Shred destroys all data keys of the owner.
+func (m *MemoryKeyProvider) Shred(owner string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, o := range m.owners {
+		if o == owner {
+			delete(m.keys, id)
+			delete(m.owners, id)
+		}
+	}
+	delete(m.current, owner)
+}
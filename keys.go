@@ -0,0 +1,58 @@
+package objst
+
+import "fmt"
+
+// Prefixes of the keys in the meta store. Grouping all
+// entries of an object in one store allows to change
+// them in a single transaction.
+const (
+	prefixMeta    = "meta/"
+	prefixName    = "name/"
+	prefixIntent  = "intent/"
+	prefixVersion = "version/"
+	prefixRef     = "ref/"
+	prefixCAS     = "cas/"
+)
+
+func metaKey(id string) []byte {
+	return []byte(prefixMeta + id)
+}
+
+func nameKey(name, owner string) []byte {
+	// choosing the name format as <name>_<owner> allows
+	// to have unique names in the context of a owner e.g.
+	// owner 1 can have foo_1 and owner 2 can have foo_2
+	// without having a duplication error.
+	return []byte(fmt.Sprintf("%s%s_%s", prefixName, name, owner))
+}
+
+// intentKey is the key of a write intent. A write intent
+// marks the payload of the object as potentially orphaned
+// until the metadata of the object has been committed.
+func intentKey(id string) []byte {
+	return []byte(prefixIntent + id)
+}
+
+// versionKey is the key of a noncurrent version of an object.
+// The version is zero padded to keep the versions ordered.
+func versionKey(id string, version int) []byte {
+	return []byte(fmt.Sprintf("%s%016x", versionPrefix(id), version))
+}
+
+func versionPrefix(id string) []byte {
+	return []byte(prefixVersion + id + "/")
+}
+
+// refKey is the key of the reference count of a payload
+// which is shared between multiple versions or objects.
+func refKey(blob string) []byte {
+	return []byte(prefixRef + blob)
+}
+
+// casKey is the key of the payload of the version if the
+// deduplication of payloads is enabled. Only payloads with
+// the same format can be shared.
+func casKey(meta *Metadata) []byte {
+	checksum := meta.Get(MetaKeyChecksum)
+	return []byte(fmt.Sprintf("%s%s.%s.%s", prefixCAS, checksum, encodingOf(meta), meta.Get(metaKeyKeyID)))
+}
@@ -0,0 +1,87 @@
+package objst
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+const (
+	bucketNamePattern = "^[a-zA-Z0-9_.-]+$"
+	// manifestFile is the file every badger
+	// store creates in its directory.
+	manifestFile = "MANIFEST"
+)
+
+func isValidBucketName(name string) bool {
+	if name == "." || name == ".." {
+		return false
+	}
+	ok, _ := regexp.MatchString(bucketNamePattern, name)
+	return ok
+}
+
+// prepareLayout creates the directory `dir` if it doesn't
+// exist. If it exists it has to be empty or contain a valid
+// bucket layout.
+func prepareLayout(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return os.MkdirAll(dir, 0o755)
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return isValidLayout(dir)
+}
+
+// isValidLayout checks if every store of a
+// bucket is present in the directory `dir`.
+func isValidLayout(dir string) error {
+	if err := isValidStore(dir, metaDir); err != nil {
+		return err
+	}
+	if hasFileBlobs(dir) {
+		return nil
+	}
+	return isValidStore(dir, dataDir)
+}
+
+// isValidStore checks if the badger store
+// `storeDir` is present in the directory `dir`.
+func isValidStore(dir, storeDir string) error {
+	manifest := filepath.Join(dir, storeDir, manifestFile)
+	info, err := os.Stat(manifest)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidBucketLayout, dir, err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%w: %s is not a regular file", ErrInvalidBucketLayout, manifest)
+	}
+	return nil
+}
+
+// hasFileBlobs reports if the bucket located in
+// `dir` stores the payloads as files.
+func hasFileBlobs(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, blobsDir))
+	return err == nil && info.IsDir()
+}
+
+// payloadBackendOf returns the payload backend of the bucket
+// located in `dir`. New buckets use the given backend.
+func payloadBackendOf(dir string, backend PayloadBackend) PayloadBackend {
+	if hasFileBlobs(dir) {
+		return PayloadBackendFiles
+	}
+	if isValidStore(dir, dataDir) == nil {
+		return PayloadBackendBadger
+	}
+	return backend
+}
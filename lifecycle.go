@@ -0,0 +1,271 @@
+package objst
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// prefixExpiry is the prefix of the entries ordering
+// the objects with an expiry by their expiry time.
+const prefixExpiry = "exp/"
+
+// expiryKey is the key of the expiry entry of an object. The
+// time is zero padded to keep the entries ordered by time.
+func expiryKey(t time.Time, id string) []byte {
+	return []byte(fmt.Sprintf("%s%020d/%s", prefixExpiry, t.UnixNano(), id))
+}
+
+// parseExpiryKey returns the expiry time and the id of the object.
+func parseExpiryKey(key []byte) (time.Time, string) {
+	ts, id, _ := strings.Cut(strings.TrimPrefix(string(key), prefixExpiry), "/")
+	n, _ := strconv.ParseInt(ts, 10, 64)
+	return time.Unix(0, n), id
+}
+
+// expiryOf returns the expiry of the object. The
+// bool reports if the object has an expiry.
+func expiryOf(meta *Metadata) (time.Time, bool, error) {
+	v := meta.Get(MetaKeyExpiresAt)
+	if v == "" {
+		return time.Time{}, false, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w: %s", ErrInvalidExpiry, v)
+	}
+	return t, true, nil
+}
+
+// isExpired reports if the expiry of the object has passed.
+// Expired objects are not visible anymore even if they have
+// not been removed yet.
+func isExpired(meta *Metadata, now time.Time) bool {
+	t, ok, err := expiryOf(meta)
+	return err == nil && ok && !t.After(now)
+}
+
+// setExpiry inserts the expiry entry of the object.
+func setExpiry(txn storeTxn, meta *Metadata) error {
+	t, ok, err := expiryOf(meta)
+	if err != nil || !ok {
+		return err
+	}
+	return txn.Set(expiryKey(t, meta.Get(MetaKeyID)), nil)
+}
+
+// deleteExpiry removes the expiry entry of the object.
+func deleteExpiry(txn storeTxn, meta *Metadata) error {
+	t, ok, err := expiryOf(meta)
+	if err != nil || !ok {
+		return err
+	}
+	return txn.Delete(expiryKey(t, meta.Get(MetaKeyID)))
+}
+
+// LifecycleRule removes objects or noncurrent versions
+// of objects after a duration.
+type LifecycleRule struct {
+	// MetaKey and Value select the objects the rule
+	// applies to. An empty MetaKey selects all objects.
+	MetaKey MetaKey `json:"metaKey,omitempty"`
+	Value   string  `json:"value,omitempty"`
+
+	// Expiration removes the matching objects which haven't
+	// been updated for the duration. Zero keeps the objects.
+	Expiration time.Duration `json:"expiration,omitempty"`
+
+	// NoncurrentExpiration removes the noncurrent versions of
+	// the matching objects which have been replaced for the
+	// duration. Zero keeps the noncurrent versions.
+	NoncurrentExpiration time.Duration `json:"noncurrentExpiration,omitempty"`
+}
+
+func (r LifecycleRule) isValid() error {
+	if r.Expiration < 0 || r.NoncurrentExpiration < 0 {
+		return fmt.Errorf("%w: durations must not be negative", ErrInvalidLifecycleRule)
+	}
+	if r.Expiration == 0 && r.NoncurrentExpiration == 0 {
+		return fmt.Errorf("%w: rule doesn't remove anything", ErrInvalidLifecycleRule)
+	}
+	return nil
+}
+
+func (r LifecycleRule) matches(meta *Metadata) bool {
+	return r.MetaKey == "" || meta.Get(r.MetaKey) == r.Value
+}
+
+// condition returns a condition selecting at least the objects
+// the rule applies to. Empty values also match objects without
+// the key so all objects have to be considered.
+func (r LifecycleRule) condition() Cond {
+	if r.MetaKey == "" || r.Value == "" {
+		return All()
+	}
+	return Eq(r.MetaKey, r.Value)
+}
+
+// SetLifecycle replaces the lifecycle rules of the bucket. The
+// rules are applied periodically in the background and can be
+// applied manually using ApplyLifecycle. The rules are persisted.
+func (b Bucket) SetLifecycle(rules ...LifecycleRule) error {
+	for _, r := range rules {
+		if err := r.isValid(); err != nil {
+			return err
+		}
+	}
+	return b.updateSettings(func(s *settings) {
+		s.Lifecycle = rules
+	})
+}
+
+// Lifecycle returns the lifecycle rules of the bucket.
+func (b Bucket) Lifecycle() []LifecycleRule {
+	return b.settings.lifecycle()
+}
+
+// ApplyLifecycle removes all expired objects and the objects and
+// noncurrent versions matching a lifecycle rule. The objects are
+// removed like any other object including their names, metadata
+// and payloads. It returns the number of removed objects and
+// versions.
+func (b Bucket) ApplyLifecycle() (int, error) {
+	now := time.Now()
+	ids, err := b.expiredIDs(now)
+	if err != nil {
+		return 0, err
+	}
+	versions := make(map[string][]int)
+	if rules := b.Lifecycle(); len(rules) > 0 {
+		err := b.meta.View(func(txn storeTxn) error {
+			return b.matchRules(txn, rules, now, func(id string, version int) {
+				if version == 0 {
+					ids = append(ids, id)
+					return
+				}
+				versions[id] = append(versions[id], version)
+			})
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	removed := 0
+	for _, id := range ids {
+		err := b.DeleteByID(id)
+		if errors.Is(err, errKeyNotFound) {
+			// removed concurrently or by another rule
+			continue
+		}
+		if err != nil {
+			return removed, err
+		}
+		removed++
+		delete(versions, id)
+	}
+	for id, vs := range versions {
+		for _, v := range vs {
+			err := b.DeleteVersion(id, v)
+			if errors.Is(err, errKeyNotFound) || errors.Is(err, ErrVersionNotFound) {
+				continue
+			}
+			if err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// expiredIDs returns the ids of all objects
+// which have expired at the given time.
+func (b Bucket) expiredIDs(now time.Time) ([]string, error) {
+	ids := make([]string, 0)
+	err := b.meta.View(func(txn storeTxn) error {
+		it := txn.Iterate([]byte(prefixExpiry), true)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			t, id := parseExpiryKey(it.Key())
+			if t.After(now) {
+				break
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	return ids, err
+}
+
+// matchRules calls fn for every object and noncurrent version
+// which has to be removed by the rules. The version is zero
+// if the whole object has to be removed. The candidates are
+// streamed from the indexes if all rules select an indexed
+// value. Otherwise the objects are visited one by one.
+func (b Bucket) matchRules(txn storeTxn, rules []LifecycleRule, now time.Time, fn func(id string, version int)) error {
+	conds := make([]Cond, 0, len(rules))
+	for _, r := range rules {
+		conds = append(conds, r.condition())
+	}
+	ids, ok := b.streamCandidates(txn, Any(conds...), "", Asc)
+	if !ok {
+		ids = newKeyStream(txn, prefixMeta, "", Asc)
+	}
+	defer ids.close()
+	for id, ok := ids.next(); ok; id, ok = ids.next() {
+		meta, err := b.getMeta(txn, id)
+		if errors.Is(err, errKeyNotFound) {
+			// index entries of concurrently removed objects
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if isExpired(meta, now) {
+			// removed by its expiry
+			continue
+		}
+		if isRuleExpired(rules, meta, now) {
+			fn(id, 0)
+			continue
+		}
+		history, err := b.history(txn, id)
+		if err != nil {
+			return err
+		}
+		for i, version := range history {
+			// a version is noncurrent since
+			// its successor has been written.
+			next := meta
+			if i+1 < len(history) {
+				next = history[i+1]
+			}
+			if isNoncurrentExpired(rules, version, next, now) {
+				fn(id, versionOf(version))
+			}
+		}
+	}
+	return nil
+}
+
+func isRuleExpired(rules []LifecycleRule, meta *Metadata, now time.Time) bool {
+	updatedAt := parseTime(meta.Get(MetaKeyUpdatedAt))
+	for _, r := range rules {
+		if r.Expiration > 0 && r.matches(meta) && !updatedAt.Add(r.Expiration).After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNoncurrentExpired(rules []LifecycleRule, version, next *Metadata, now time.Time) bool {
+	replacedAt := parseTime(next.Get(MetaKeyUpdatedAt))
+	for _, r := range rules {
+		if r.NoncurrentExpiration > 0 && r.matches(version) && !replacedAt.Add(r.NoncurrentExpiration).After(now) {
+			return true
+		}
+	}
+	return false
+}
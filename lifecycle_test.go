@@ -0,0 +1,280 @@
+package objst
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestExpiry(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	expired := tEnv.obj()
+	expired.SetExpiry(time.Now().Add(-time.Second))
+	valid := tEnv.obj()
+	valid.SetExpiry(time.Now().Add(time.Hour))
+	if err := b.BatchCreate([]*Object{expired, valid}); err != nil {
+		t.Error(err)
+		return
+	}
+	n, err := b.ApplyLifecycle()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 removed object. Got: %d", n)
+	}
+	if _, err := b.GetByID(expired.ID()); err == nil {
+		t.Fatalf("expired object should be removed")
+	}
+	if _, err := b.GetByName(expired.Name(), expired.Owner()); err == nil {
+		t.Fatalf("name of the expired object should be removed")
+	}
+	if _, err := b.blobs.Open(blobOf(expired.meta)); err == nil {
+		t.Fatalf("payload of the expired object should be removed")
+	}
+	if _, err := b.GetByID(valid.ID()); err != nil {
+		t.Fatalf("object should not be expired yet. Got: %v", err)
+	}
+}
+
+func TestExpiryHidden(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	o := tEnv.obj()
+	o.SetExpiry(time.Now().Add(-time.Second))
+	if err := b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	// expired objects are hidden before they are removed
+	tests := []struct {
+		name string
+		fn   func() error
+	}{
+		{
+			name: "get",
+			fn: func() error {
+				_, err := b.GetByID(o.ID())
+				return err
+			},
+		},
+		{
+			name: "describe",
+			fn: func() error {
+				_, err := b.Describe(o.ID())
+				return err
+			},
+		},
+		{
+			name: "read",
+			fn: func() error {
+				return b.ReadContext(context.Background(), o.ID(), io.Discard)
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.fn(); !errors.Is(err, ErrObjectNotFound) {
+				t.Fatalf("expired object should not be found. Got: %v", err)
+			}
+		})
+	}
+	objs, err := b.Get(NewQuery().Owner(o.Owner()))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(objs) != 0 {
+		t.Fatalf("expired object should not match a query. Got: %d objects", len(objs))
+	}
+}
+
+func TestExpiryNameReuse(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	expired := tEnv.obj()
+	expired.SetExpiry(time.Now().Add(-time.Second))
+	if err := b.Create(expired); err != nil {
+		t.Error(err)
+		return
+	}
+	// the name of an expired object is free before it's removed
+	o, _ := NewObject(expired.Name(), expired.Owner())
+	o.Write(tEnv.payload(10))
+	if err := b.Create(o); err != nil {
+		t.Fatalf("name of the expired object should be free. Got: %v", err)
+	}
+	got, err := b.GetByName(o.Name(), o.Owner())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if got.ID() != o.ID() {
+		t.Fatalf("new object should hold the name. Got: %s", got.ID())
+	}
+	if _, err := b.blobs.Open(blobOf(expired.meta)); !errors.Is(err, errKeyNotFound) {
+		t.Fatalf("payload of the expired object should be removed. Got: %v", err)
+	}
+	u, err := b.Usage(o.Owner())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if u.Objects != 1 {
+		t.Fatalf("expired object should be refunded. Got: %d objects", u.Objects)
+	}
+	other, _ := NewObject(tEnv.name(), o.Owner())
+	other.Write(tEnv.payload(10))
+	other.SetExpiry(time.Now().Add(-time.Second))
+	if err := b.Create(other); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := b.Rename(o.ID(), other.Name()); err != nil {
+		t.Fatalf("object should be renamed to the name of the expired object. Got: %v", err)
+	}
+}
+
+func TestExpiryUpdate(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	o := tEnv.obj()
+	o.SetExpiry(time.Now().Add(time.Hour))
+	if err := b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	_, err := b.UpdateMeta(o.ID(), map[MetaKey]string{MetaKeyExpiresAt: "tomorrow"}, "")
+	if !errors.Is(err, ErrInvalidExpiry) {
+		t.Fatalf("invalid expiry should be rejected. Got: %v", err)
+	}
+	past := formatTime(time.Now().Add(-time.Second))
+	if _, err := b.UpdateMeta(o.ID(), map[MetaKey]string{MetaKeyExpiresAt: past}, ""); err != nil {
+		t.Error(err)
+		return
+	}
+	n, err := b.ApplyLifecycle()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if n != 1 {
+		t.Fatalf("object should expire after the update. Got: %d removed objects", n)
+	}
+}
+
+func TestLifecycleRule(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	tmp := tEnv.obj()
+	tmp.SetMetaKey("tmp", "true")
+	kept := tEnv.obj()
+	if err := b.BatchCreate([]*Object{tmp, kept}); err != nil {
+		t.Error(err)
+		return
+	}
+	// the candidates of the rule are found using the index
+	if err := b.CreateIndex("tmp"); err != nil {
+		t.Error(err)
+		return
+	}
+	err := b.SetLifecycle(LifecycleRule{MetaKey: "tmp", Value: "true", Expiration: time.Nanosecond})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := b.ApplyLifecycle(); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := b.GetByID(tmp.ID()); err == nil {
+		t.Fatalf("object matching the rule should be removed")
+	}
+	if _, err := b.GetByID(kept.ID()); err != nil {
+		t.Fatalf("object not matching the rule should be kept. Got: %v", err)
+	}
+	if err := b.SetLifecycle(LifecycleRule{MetaKey: "tmp", Value: "true"}); !errors.Is(err, ErrInvalidLifecycleRule) {
+		t.Fatalf("rule without a duration should be invalid. Got: %v", err)
+	}
+}
+
+func TestLifecycleNoncurrentRule(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	if err := b.SetVersioning(true); err != nil {
+		t.Error(err)
+		return
+	}
+	id, pls := createVersions(t, b, tEnv.name(), tEnv.owner(), 3)
+	if err := b.SetLifecycle(LifecycleRule{NoncurrentExpiration: time.Nanosecond}); err != nil {
+		t.Error(err)
+		return
+	}
+	n, err := b.ApplyLifecycle()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 removed versions. Got: %d", n)
+	}
+	ds, err := b.Versions(id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(ds) != 1 || ds[0].Version() != 3 {
+		t.Fatalf("only the current version should be kept. Got: %d versions", len(ds))
+	}
+	pl, err := b.GetPayload(id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(pl) != string(pls[2]) {
+		t.Fatalf("payload of the current version is not correct")
+	}
+}
+
+func TestHTTPUploadTTL(t *testing.T) {
+	target, err := url.JoinPath(tEnv.ts.URL, route, "upload")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	params := map[string]string{"ttl": "1h"}
+	r, err := tEnv.newUploadRequest(target, params, tEnv.h.opts.FormKey, "testdata/images/2500KB.jpg")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	ctx := context.WithValue(r.Context(), CtxKeyOwner, uuid.NewString())
+	w := httptest.NewRecorder()
+	tEnv.h.ServeHTTP(w, r.WithContext(ctx))
+	if w.Code != http.StatusOK {
+		t.Fatalf("statuscode is not %d. Got: %d", http.StatusOK, w.Code)
+	}
+	var model objectModel
+	if err := json.NewDecoder(w.Body).Decode(&model); err != nil {
+		t.Error(err)
+		return
+	}
+	d, err := tEnv.b.Describe(model.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if d.ExpiresAt().IsZero() || d.ExpiresAt().After(time.Now().Add(time.Hour)) {
+		t.Fatalf("expiry should be set using the ttl. Got: %v", d.ExpiresAt())
+	}
+}
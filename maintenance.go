@@ -0,0 +1,164 @@
+package objst
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// defaultGCDiscardRatio is the ratio of stale data from
+// which on a value log file of a store gets rewritten.
+const defaultGCDiscardRatio = 0.5
+
+// compactor is implemented by stores which have
+// to be compacted to reclaim the disk space of
+// removed data.
+type compactor interface {
+	// Compact reclaims the disk space of removed data and returns
+	// the number of reclaimed bytes. If full is set the whole
+	// store is compacted which is more expensive.
+	Compact(full bool, discardRatio float64) (int64, error)
+}
+
+// maintenance runs the garbage collection and the
+// lifecycle rules of the bucket in the background.
+type maintenance struct {
+	discardRatio float64
+	logger       badger.Logger
+	stop         chan struct{}
+	wg           sync.WaitGroup
+	once         sync.Once
+}
+
+func newMaintenance(opts BucketOptions) *maintenance {
+	m := &maintenance{
+		discardRatio: opts.GCDiscardRatio,
+		logger:       opts.Logger,
+		stop:         make(chan struct{}),
+	}
+	if m.discardRatio <= 0 || m.discardRatio >= 1 {
+		m.discardRatio = defaultGCDiscardRatio
+	}
+	return m
+}
+
+// start runs the task every interval until the maintenance
+// is stopped. A non positive interval disables the task.
+func (m *maintenance) start(task string, interval time.Duration, fn func() error) {
+	if interval <= 0 {
+		return
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := fn(); err != nil && m.logger != nil {
+					m.logger.Warningf("objst: %s failed: %v", task, err)
+				}
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+// shutdown stops all tasks and waits
+// until the running tasks have finished.
+func (m *maintenance) shutdown() {
+	m.once.Do(func() {
+		close(m.stop)
+	})
+	m.wg.Wait()
+}
+
+// Compact compacts all stores of the bucket and runs the garbage
+// collection of the value logs to reclaim the disk space of removed
+// objects. It returns the number of reclaimed bytes. Compacting is
+// expensive and should be done during periods of low traffic.
+func (b Bucket) Compact() (int64, error) {
+	return b.compact(true)
+}
+
+// collectGarbage runs the garbage collection of the value logs.
+func (b Bucket) collectGarbage() error {
+	_, err := b.compact(false)
+	return err
+}
+
+// expire applies the lifecycle rules and removes expired objects.
+func (b Bucket) expire() error {
+	_, err := b.ApplyLifecycle()
+	return err
+}
+
+func (b Bucket) compact(full bool) (int64, error) {
+	var reclaimed int64
+	for _, s := range []any{b.blobs, b.meta} {
+		c, ok := s.(compactor)
+		if !ok {
+			continue
+		}
+		n, err := c.Compact(full, b.maintenance.discardRatio)
+		reclaimed += n
+		if err != nil {
+			return reclaimed, err
+		}
+	}
+	return reclaimed, nil
+}
+
+// Compact flattens the LSM tree if full is set and rewrites
+// all value log files exceeding the discard ratio.
+func (s *badgerStore) Compact(full bool, discardRatio float64) (int64, error) {
+	opts := s.db.Opts()
+	if opts.InMemory {
+		return 0, nil
+	}
+	before, err := diskSize(opts.Dir)
+	if err != nil {
+		return 0, err
+	}
+	if full {
+		workers := opts.NumCompactors
+		if workers < 1 {
+			workers = 1
+		}
+		if err := s.db.Flatten(workers); err != nil {
+			return 0, err
+		}
+	}
+	for {
+		err := s.db.RunValueLogGC(discardRatio)
+		if errors.Is(err, badger.ErrNoRewrite) || errors.Is(err, badger.ErrRejected) {
+			// nothing left to rewrite or a garbage
+			// collection is already running.
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	after, err := diskSize(opts.Dir)
+	if err != nil {
+		return 0, err
+	}
+	if after > before {
+		// the store has grown by concurrent writes
+		return 0, nil
+	}
+	return before - after, nil
+}
+
+func (c chunkStore) Compact(full bool, discardRatio float64) (int64, error) {
+	s, ok := c.s.(compactor)
+	if !ok {
+		return 0, nil
+	}
+	return s.Compact(full, discardRatio)
+}
@@ -0,0 +1,85 @@
+package objst
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func TestCompact(t *testing.T) {
+	b, _ := newTempBucket(t, func(opts *BucketOptions) {
+		opts.GCInterval = 0
+		// flush the memtable of the payload store often and
+		// leave the compaction of the tables to Compact.
+		opts.Payload.MemTableSize = 8 << 20
+		opts.Payload.Tune = func(o badger.Options) badger.Options {
+			return o.WithNumCompactors(0).WithNumLevelZeroTables(1)
+		}
+	})
+	defer b.Shutdown()
+	objs := make([]*Object, 0, 10)
+	for i := 0; i < 10; i++ {
+		o, _ := NewObject(tEnv.name(), tEnv.owner())
+		o.Write(tEnv.payload(1 << 20))
+		if err := b.Create(o); err != nil {
+			t.Error(err)
+			return
+		}
+		objs = append(objs, o)
+	}
+	for _, o := range objs {
+		if err := b.DeleteByID(o.ID()); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	// the removed chunks can only be reclaimed
+	// after the memtable has been flushed.
+	for i := 0; i < 10; i++ {
+		o, _ := NewObject(tEnv.name(), tEnv.owner())
+		o.Write(tEnv.payload(1 << 20))
+		if err := b.Create(o); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	n, err := b.Compact()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if n <= 0 {
+		t.Fatalf("disk space of the removed objects should be reclaimed. Got: %d", n)
+	}
+}
+
+func TestCompactMemoryBucket(t *testing.T) {
+	n, err := tEnv.b.Compact()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if n != 0 {
+		t.Fatalf("memory bucket should not reclaim disk space. Got: %d", n)
+	}
+}
+
+func TestMaintenanceStopsOnShutdown(t *testing.T) {
+	m := newMaintenance(BucketOptions{})
+	var runs atomic.Int64
+	m.start("count", time.Millisecond, func() error {
+		runs.Add(1)
+		return nil
+	})
+	for runs.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	m.shutdown()
+	n := runs.Load()
+	time.Sleep(10 * time.Millisecond)
+	if runs.Load() != n {
+		t.Fatalf("task should not run after the shutdown. Got: %d runs after %d", runs.Load(), n)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"time"
 
 	"golang.org/x/exp/slices"
 )
@@ -18,6 +19,25 @@ const (
 	MetaKeyName        MetaKey = "name"
 	MetaKeyID          MetaKey = "id"
 	MetaKeyOwner       MetaKey = "owner"
+	MetaKeySize        MetaKey = "size"
+	MetaKeyChecksum    MetaKey = "checksum"
+	MetaKeyUpdatedAt   MetaKey = "updatedAt"
+	MetaKeyVersion     MetaKey = "version"
+	// MetaKeyExpiresAt is the time in RFC 3339 format after
+	// which the object will be removed. It can be set by the
+	// user like any other user defined key.
+	MetaKeyExpiresAt MetaKey = "expiresAt"
+
+	// metaKeyBlob references the payload of the
+	// object. It's managed internally and must
+	// not be exposed to the user.
+	metaKeyBlob MetaKey = "blob"
+	// metaKeyEncoding is the compression
+	// of the stored payload.
+	metaKeyEncoding MetaKey = "encoding"
+	// metaKeyKeyID is the id of the data
+	// key used to encrypt the payload.
+	metaKeyKeyID MetaKey = "keyID"
 )
 
 func (m MetaKey) String() string {
@@ -34,8 +54,20 @@ type Metadata struct {
 
 func NewMetadata() *Metadata {
 	return &Metadata{
-		data:       make(map[MetaKey]string),
-		systemKeys: []MetaKey{MetaKeyID, MetaKeyCreatedAt, MetaKeyName, MetaKeyOwner},
+		data: make(map[MetaKey]string),
+		systemKeys: []MetaKey{
+			MetaKeyID,
+			MetaKeyCreatedAt,
+			MetaKeyUpdatedAt,
+			MetaKeyName,
+			MetaKeyOwner,
+			MetaKeySize,
+			MetaKeyChecksum,
+			MetaKeyVersion,
+			metaKeyBlob,
+			metaKeyEncoding,
+			metaKeyKeyID,
+		},
 	}
 }
 
@@ -92,6 +124,15 @@ func (m Metadata) set(k MetaKey, v string) {
 	m.data[k] = v
 }
 
+// clone returns a deep copy of the metadata.
+func (m Metadata) clone() *Metadata {
+	c := NewMetadata()
+	for k, v := range m.data {
+		c.data[k] = v
+	}
+	return c
+}
+
 func (m Metadata) UserDefinedPairs() map[MetaKey]string {
 	res := make(map[MetaKey]string)
 	for k, v := range m.data {
@@ -147,6 +188,18 @@ func (m Metadata) isEmpty() bool {
 	return len(m.data) == 0
 }
 
+// formatTime formats t as a timestamp which
+// can be compared by the conditions of a query.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// parseTime parses a timestamp created by formatTime.
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
+
 func metaPattern(pattern string) string {
 	return fmt.Sprintf("^%s$", pattern)
 }
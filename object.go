@@ -5,6 +5,7 @@ import (
 	"io"
 	"mime"
 	"path/filepath"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -60,6 +61,28 @@ func (o Object) Owner() string {
 	return o.meta.Get(MetaKeyOwner)
 }
 
+// CreatedAt returns the time the object was inserted
+// into the store. It's zero for objects not inserted yet.
+func (o Object) CreatedAt() time.Time {
+	return parseTime(o.meta.Get(MetaKeyCreatedAt))
+}
+
+// SetExpiry sets the time after which the object will be
+// removed. A zero time removes the expiry of the object.
+func (o *Object) SetExpiry(t time.Time) {
+	if t.IsZero() {
+		o.meta.Del(MetaKeyExpiresAt)
+		return
+	}
+	o.meta.Set(MetaKeyExpiresAt, formatTime(t))
+}
+
+// ExpiresAt returns the time after which the object will
+// be removed. It's zero if the object doesn't expire.
+func (o Object) ExpiresAt() time.Time {
+	return parseTime(o.meta.Get(MetaKeyExpiresAt))
+}
+
 func (o Object) Payload() []byte {
 	return o.pl.Bytes()
 }
@@ -103,16 +126,23 @@ func (o *Object) Unmarshal(data []byte) error {
 }
 
 func (o Object) isValid() error {
-	if !o.HasMetaKey(MetaKeyContentType) {
-		return ErrContentTypeNotExist
-	}
 	if len(o.pl.Bytes()) == 0 {
 		return ErrEmptyPayload
 	}
+	return o.isValidMeta()
+}
+
+// isValidMeta validates the metadata of the
+// object without considering the payload.
+func (o Object) isValidMeta() error {
+	if !o.HasMetaKey(MetaKeyContentType) {
+		return ErrContentTypeNotExist
+	}
 	if !isValidObjectName(o.Name()) {
 		return ErrInvalidNamePattern
 	}
-	return nil
+	_, _, err := expiryOf(o.meta)
+	return err
 }
 
 // Write will write the data iff the object is mutable.
@@ -151,12 +181,7 @@ func (o *Object) Reset() {
 }
 
 func (o *Object) ToModel() *objectModel {
-	return &objectModel{
-		ID:       o.ID(),
-		Name:     o.Name(),
-		Owner:    o.Owner(),
-		Metadata: o.meta.UserDefinedPairs(),
-	}
+	return newObjectModel(o.meta)
 }
 
 func (o *Object) markAsImmutable() {
@@ -30,7 +30,7 @@ func NewEnv() (*Env, error) {
 	opts := objst.NewDefaultBucketOptions()
 	// turn of default loggin of badger
 	opts.Logger = nil
-	b, err := objst.NewBucket(opts)
+	b, err := objst.NewMemoryBucket(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -110,9 +110,6 @@ func (e Env) Destroy() error {
 	if err := e.b.Shutdown(); err != nil {
 		return err
 	}
-	if err := os.RemoveAll(e.b.BasePath); err != nil {
-		return err
-	}
 	e.ts.Close()
 	return nil
 }
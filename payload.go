@@ -0,0 +1,371 @@
+package objst
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// payloadChunkSize is the maximum size of one chunk
+// of a payload. Payloads are split into chunks to be
+// able to stream them from and to the store without
+// holding the whole payload in memory. Chunks are
+// smaller than the value threshold of badger because
+// in-memory stores can't hold larger values.
+const payloadChunkSize = 512 << 10
+
+func chunkKey(id string, n int) []byte {
+	return []byte(fmt.Sprintf("%s/%016x", id, n))
+}
+
+func chunkPrefix(id string) []byte {
+	return []byte(id + "/")
+}
+
+// ctxReader reads from r until ctx is done. Once ctx
+// is done every read fails with the error of ctx.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func newCtxReader(ctx context.Context, r io.Reader) io.Reader {
+	if ctx.Done() == nil {
+		// the context can never be done
+		return r
+	}
+	return ctxReader{ctx: ctx, r: r}
+}
+
+func (c ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// payloadFormat describes how a payload is stored.
+type payloadFormat struct {
+	compression Compression
+	// keyID is the id of the data key used to encrypt
+	// the payload. The payload isn't encrypted if empty.
+	keyID string
+	key   []byte
+}
+
+// formatFor returns the format of a new payload of the object.
+func (b Bucket) formatFor(meta *Metadata) (payloadFormat, error) {
+	f := payloadFormat{
+		compression: b.Compression().algorithmFor(meta.Get(MetaKeyContentType)),
+	}
+	if b.keys == nil {
+		return f, nil
+	}
+	id, key, err := b.keys.DataKey(meta.Get(MetaKeyOwner))
+	if err != nil {
+		return f, err
+	}
+	f.keyID, f.key = id, key
+	return f, nil
+}
+
+// payloadStat describes a written payload.
+type payloadStat struct {
+	size int64
+	// checksum is the hex encoded
+	// SHA-256 hash of the payload.
+	checksum string
+	format   payloadFormat
+}
+
+// stamp records the stat and the time of the
+// modification in the system metadata.
+func (s payloadStat) stamp(meta *Metadata) {
+	now := formatTime(time.Now())
+	if !meta.Has(MetaKeyCreatedAt) {
+		meta.set(MetaKeyCreatedAt, now)
+	}
+	meta.set(MetaKeyUpdatedAt, now)
+	meta.set(MetaKeySize, strconv.FormatInt(s.size, 10))
+	meta.set(MetaKeyChecksum, s.checksum)
+	delete(meta.data, metaKeyEncoding)
+	delete(meta.data, metaKeyKeyID)
+	if s.format.compression != CompressionNone {
+		meta.set(metaKeyEncoding, string(s.format.compression))
+	}
+	if s.format.keyID != "" {
+		meta.set(metaKeyKeyID, s.format.keyID)
+	}
+}
+
+// writePayload compresses and encrypts the data
+// read from r using the format and writes it to dst.
+func writePayload(dst io.Writer, r io.Reader, f payloadFormat) (payloadStat, error) {
+	stat := payloadStat{format: f}
+	h := sha256.New()
+	var ew io.WriteCloser = nopWriteCloser{dst}
+	if f.keyID != "" {
+		w, err := encrypt(dst, f.key)
+		if err != nil {
+			return stat, err
+		}
+		ew = w
+	}
+	w, err := compress(ew, f.compression)
+	if err != nil {
+		return stat, err
+	}
+	stat.size, err = io.Copy(w, io.TeeReader(r, h))
+	if err != nil {
+		return stat, err
+	}
+	// the compressor has to be flushed before the last
+	// segment of the encrypted payload can be written.
+	if err := w.Close(); err != nil {
+		return stat, err
+	}
+	if err := ew.Close(); err != nil {
+		return stat, err
+	}
+	stat.checksum = hex.EncodeToString(h.Sum(nil))
+	return stat, nil
+}
+
+// insertPayload streams the data of r into the payload store.
+func (b Bucket) insertPayload(blob string, r io.Reader, f payloadFormat) (payloadStat, error) {
+	w, err := b.blobs.Create(blob)
+	if err != nil {
+		return payloadStat{}, err
+	}
+	stat, err := writePayload(w, r, f)
+	if err != nil {
+		w.Abort()
+		return stat, err
+	}
+	return stat, w.Commit()
+}
+
+// chunkWriter splits the written data into
+// chunks and sets them in the write batch.
+type chunkWriter struct {
+	wb storeBatch
+	id string
+	// n is the number of the next chunk
+	n     int
+	chunk []byte
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if w.chunk == nil {
+			// every chunk needs its own buffer because the
+			// write batch holds the value until it's flushed.
+			w.chunk = make([]byte, 0, payloadChunkSize)
+		}
+		n := copy(w.chunk[len(w.chunk):cap(w.chunk)], p)
+		w.chunk = w.chunk[:len(w.chunk)+n]
+		p = p[n:]
+		written += n
+		if len(w.chunk) == payloadChunkSize {
+			if err := w.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// flush sets the buffered chunk in the write batch.
+func (w *chunkWriter) flush() error {
+	if len(w.chunk) == 0 {
+		return nil
+	}
+	if err := w.wb.Set(chunkKey(w.id, w.n), w.chunk); err != nil {
+		return err
+	}
+	w.chunk = nil
+	w.n++
+	return nil
+}
+
+// chunkReader reads the stored chunks of a
+// payload one after another from the store.
+type chunkReader struct {
+	db store
+	id string
+	// n is the number of the next chunk
+	n     int
+	chunk []byte
+}
+
+func newChunkReader(db store, id string) (*chunkReader, error) {
+	r := &chunkReader{
+		db: db,
+		id: id,
+	}
+	// fetching the first chunk eagerly allows
+	// to report a missing payload immediately.
+	if err := r.next(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.chunk) == 0 {
+		err := r.next()
+		if errors.Is(err, errKeyNotFound) {
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
+
+func (r *chunkReader) Close() error {
+	r.chunk = nil
+	return nil
+}
+
+func (r *chunkReader) next() error {
+	return r.db.View(func(txn storeTxn) error {
+		chunk, err := txn.Get(chunkKey(r.id, r.n))
+		if err != nil {
+			return err
+		}
+		r.chunk = chunk
+		r.n++
+		return nil
+	})
+}
+
+// payloadReader decompresses the stored payload
+// and verifies the checksum after the last byte
+// has been read.
+type payloadReader struct {
+	id string
+	r  io.ReadCloser
+	// checksum is the expected checksum
+	// of the payload. If empty the payload
+	// will not be verified.
+	checksum string
+	h        hash.Hash
+}
+
+// openStored returns a reader for the stored payload of the
+// object which is decrypted but not decompressed.
+func (b Bucket) openStored(meta *Metadata) (io.ReadCloser, error) {
+	cr, err := b.blobs.Open(blobOf(meta))
+	if err != nil {
+		return nil, err
+	}
+	if !isEncrypted(meta) {
+		return cr, nil
+	}
+	key, err := b.dataKeyOf(meta)
+	if err != nil {
+		return nil, errors.Join(err, cr.Close())
+	}
+	r, err := decrypt(cr, key)
+	if err != nil {
+		return nil, errors.Join(err, cr.Close())
+	}
+	return r, nil
+}
+
+// openPayload returns a reader for the payload of the object.
+func (b Bucket) openPayload(meta *Metadata) (*payloadReader, error) {
+	sr, err := b.openStored(meta)
+	if err != nil {
+		return nil, err
+	}
+	r, err := decompress(sr, encodingOf(meta))
+	if err != nil {
+		return nil, errors.Join(err, sr.Close())
+	}
+	return &payloadReader{
+		id:       meta.Get(MetaKeyID),
+		r:        r,
+		checksum: meta.Get(MetaKeyChecksum),
+		h:        sha256.New(),
+	}, nil
+}
+
+// openFile returns the file of the payload if the payload is
+// stored as a plain file which can be served without decoding
+// e.g. using sendfile. The checksum of the file isn't verified.
+func (b Bucket) openFile(meta *Metadata) (*os.File, bool, error) {
+	fs, ok := b.blobs.(*fileStore)
+	if !ok || isEncrypted(meta) || encodingOf(meta) != CompressionNone {
+		return nil, false, nil
+	}
+	f, err := fs.openFile(blobOf(meta))
+	if err != nil {
+		return nil, false, err
+	}
+	return f, true, nil
+}
+
+// fileCopySize is the number of bytes of a file
+// copied between two checks of the context.
+const fileCopySize = 4 << 20
+
+// copyFile copies the file to w and stops with the error of
+// ctx once ctx is done. io.CopyN keeps the file accessible
+// to the ReaderFrom of w e.g. sendfile of a connection.
+func copyFile(ctx context.Context, w io.Writer, f *os.File) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		_, err := io.CopyN(w, f, fileCopySize)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// blobOf returns the id of the payload of the object.
+func blobOf(meta *Metadata) string {
+	return meta.Get(metaKeyBlob)
+}
+
+func (r *payloadReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.h.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		return n, r.verify()
+	}
+	return n, err
+}
+
+// verify compares the checksum of the read payload with the
+// expected checksum. It returns io.EOF if both are equal.
+func (r *payloadReader) verify() error {
+	if r.checksum == "" {
+		return io.EOF
+	}
+	if sum := hex.EncodeToString(r.h.Sum(nil)); sum != r.checksum {
+		return fmt.Errorf("%w: payload of %s has the checksum %s instead of %s", ErrChecksumMismatch, r.id, sum, r.checksum)
+	}
+	return io.EOF
+}
+
+func (r *payloadReader) Close() error {
+	return r.r.Close()
+}
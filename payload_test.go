@@ -0,0 +1,88 @@
+package objst
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCreateFromChunkedPayload(t *testing.T) {
+	o := tEnv.emptyObj()
+	pl := tEnv.payload(payloadChunkSize*2 + payloadChunkSize/2)
+	if err := tEnv.b.CreateFrom(o, bytes.NewReader(pl)); err != nil {
+		t.Error(err)
+		return
+	}
+	buf := new(bytes.Buffer)
+	if err := tEnv.b.Read(o.ID(), buf); err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(buf.Bytes(), pl) {
+		t.Fatalf("streamed payload is not the same. Got: %d bytes. Expected: %d bytes", buf.Len(), len(pl))
+	}
+	meta, err := tEnv.b.GetMeta(o.ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if meta.Get(MetaKeySize) != strconv.Itoa(len(pl)) {
+		t.Fatalf("size is not recorded correctly. Got: %s. Expected: %d", meta.Get(MetaKeySize), len(pl))
+	}
+}
+
+func TestCreateFromEmptyPayload(t *testing.T) {
+	o := tEnv.emptyObj()
+	if err := tEnv.b.CreateFrom(o, bytes.NewReader(nil)); !errors.Is(err, ErrEmptyPayload) {
+		t.Fatalf("empty payload should not be created. Got: %v", err)
+	}
+	if tEnv.b.isNameExisting(o.Name(), o.Owner()) {
+		t.Fatalf("name of the object should not exist")
+	}
+}
+
+func TestSystemMetadata(t *testing.T) {
+	before := time.Now()
+	o := tEnv.obj()
+	o.SetMetaKey(MetaKeyCreatedAt, "not a timestamp")
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	d, err := tEnv.b.Describe(o.ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if d.CreatedAt().Before(before) || d.CreatedAt().After(time.Now()) {
+		t.Fatalf("createdAt is not stamped correctly. Got: %s", d.GetMetaKey(MetaKeyCreatedAt))
+	}
+	if !d.UpdatedAt().Equal(d.CreatedAt()) {
+		t.Fatalf("updatedAt should be equal to createdAt. Got: %s", d.UpdatedAt())
+	}
+	m := d.ToModel()
+	if m.Size != d.Size() || m.Checksum != d.Checksum() || m.CreatedAt == "" {
+		t.Fatalf("model is missing the system metadata. Got: %+v", m)
+	}
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	o := tEnv.obj()
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	err := tEnv.b.blobs.(chunkStore).s.Update(func(txn storeTxn) error {
+		return txn.Set(chunkKey(blobOf(o.meta), 0), tEnv.payload(10))
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := tEnv.b.Read(o.ID(), io.Discard); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("corrupted payload should be detected. Got: %v", err)
+	}
+}
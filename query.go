@@ -14,6 +14,16 @@ const (
 	And
 )
 
+type order int
+
+const (
+	// ascending order
+	Asc order = iota + 1
+
+	// descending order
+	Desc
+)
+
 type operation int
 
 const (
@@ -26,8 +36,21 @@ type Query struct {
 	params *Metadata
 	// logical action of the meta datas
 	act action
+	// where are the conditions which have
+	// to be fulfilled additionally to the params.
+	where []Cond
 
 	op operation
+
+	// limit is the maximum number of
+	// objects of a page. 0 means no limit.
+	limit int
+	// cursor of the previous page
+	cursor string
+	// sortKey is the key the objects will be
+	// sorted by. By default they are sorted by id.
+	sortKey MetaKey
+	order   order
 }
 
 func NewQuery() *Query {
@@ -35,6 +58,7 @@ func NewQuery() *Query {
 		params: NewMetadata(),
 		act:    Or,
 		op:     OperationGet,
+		order:  Asc,
 	}
 }
 
@@ -68,20 +92,75 @@ func (q *Query) Param(k MetaKey, v string) *Query {
 	return q
 }
 
+// Where adds a condition to the query. The objects have to
+// fulfill all conditions and the params of the query.
+func (q *Query) Where(c Cond) *Query {
+	q.where = append(q.where, c)
+	return q
+}
+
 func (q *Query) Operation(op operation) *Query {
 	q.op = op
 	return q
 }
 
+// Limit sets the maximum number of
+// objects returned for one page.
+func (q *Query) Limit(n int) *Query {
+	q.limit = n
+	return q
+}
+
+// Cursor sets the cursor returned with the previous
+// page to continue the query with the next page.
+func (q *Query) Cursor(c string) *Query {
+	q.cursor = c
+	return q
+}
+
+// SortBy sets the key and the order the objects will
+// be sorted by. Numbers and timestamps will be sorted
+// by value and every other value lexicographically.
+func (q *Query) SortBy(k MetaKey, o order) *Query {
+	q.sortKey = k
+	q.order = o
+	return q
+}
+
+// condition returns the condition combining
+// the params and the conditions of the query. An
+// empty query is rejected by isValid and matches
+// no object at all.
+func (q *Query) condition() Cond {
+	conds := make([]Cond, 0, len(q.where)+1)
+	if !q.params.isEmpty() {
+		conds = append(conds, paramsCond(q.params, q.act))
+	}
+	conds = append(conds, q.where...)
+	switch len(conds) {
+	case 0:
+		return Any()
+	case 1:
+		return conds[0]
+	}
+	return All(conds...)
+}
+
 func (q *Query) isValid() error {
-	if q.params.isEmpty() {
+	if q.params.isEmpty() && len(q.where) == 0 {
 		return ErrEmptyQuery
 	}
+	if err := validateConds(q.where); err != nil {
+		return err
+	}
+	if q.limit < 0 {
+		return ErrNegativeLimit
+	}
 	if !isValidUUID(q.params.Get(MetaKeyOwner)) {
-		return fmt.Errorf("invalid uuid for the field `owner`: %s", q.params.Get(MetaKeyOwner))
+		return fmt.Errorf("%w for the field `owner`: %s", ErrInvalidUUID, q.params.Get(MetaKeyOwner))
 	}
 	if !isValidUUID(q.params.Get(MetaKeyID)) {
-		return fmt.Errorf("invalid uuid for the field `id`: %s", q.params.Get(MetaKeyID))
+		return fmt.Errorf("%w for the field `id`: %s", ErrInvalidUUID, q.params.Get(MetaKeyID))
 	}
 	if q.params.Get(MetaKeyName) != "" && q.params.Get(MetaKeyOwner) == "" {
 		return ErrNameOwnerCtxMissing
@@ -0,0 +1,74 @@
+package objst
+
+import (
+	"errors"
+	"strconv"
+)
+
+// refs returns the number of versions of all objects referencing
+// the payload. A payload without a reference count is referenced
+// once which is the case for every payload which isn't shared.
+func refs(txn storeTxn, blob string) (int, error) {
+	val, err := txn.Get(refKey(blob))
+	if errors.Is(err, errKeyNotFound) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(val))
+}
+
+func setRefs(txn storeTxn, blob string, n int) error {
+	if n == 1 {
+		return txn.Delete(refKey(blob))
+	}
+	return txn.Set(refKey(blob), []byte(strconv.Itoa(n)))
+}
+
+// retainBlob adds a reference to the payload.
+func retainBlob(txn storeTxn, blob string) error {
+	n, err := refs(txn, blob)
+	if err != nil {
+		return err
+	}
+	return setRefs(txn, blob, n+1)
+}
+
+// releaseBlob removes a reference to the payload of the version.
+// If it was the last reference, a write intent is set for the
+// payload and true is returned. The payload has to be removed
+// after the transaction has been committed using resolveIntent.
+func releaseBlob(txn storeTxn, meta *Metadata) (bool, error) {
+	blob := blobOf(meta)
+	n, err := refs(txn, blob)
+	if err != nil {
+		return false, err
+	}
+	if n > 1 {
+		return false, setRefs(txn, blob, n-1)
+	}
+	if err := txn.Delete(refKey(blob)); err != nil {
+		return false, err
+	}
+	if err := forgetBlob(txn, meta); err != nil {
+		return false, err
+	}
+	return true, txn.Set(intentKey(blob), nil)
+}
+
+// releaseBlobs releases the payloads of all given
+// versions and returns the unreferenced payloads.
+func releaseBlobs(txn storeTxn, metas []*Metadata) ([]string, error) {
+	orphans := make([]string, 0, len(metas))
+	for _, meta := range metas {
+		orphan, err := releaseBlob(txn, meta)
+		if err != nil {
+			return nil, err
+		}
+		if orphan {
+			orphans = append(orphans, blobOf(meta))
+		}
+	}
+	return orphans, nil
+}
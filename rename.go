@@ -0,0 +1,133 @@
+package objst
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Rename changes the name of the object keeping its id. It
+// fails if the owner already has an object with the new name.
+func (b Bucket) Rename(id, name string) (*Descriptor, error) {
+	if !isValidObjectName(name) {
+		return nil, ErrInvalidNamePattern
+	}
+	return b.move(id, func(txn storeTxn, cur, meta *Metadata) error {
+		meta.set(MetaKeyName, name)
+		return nil
+	})
+}
+
+// Transfer changes the owner of the object keeping its id. It
+// fails if the new owner already has an object with the name.
+// If the payloads are envelope encrypted the current payload is
+// encrypted again using the data key of the new owner. Noncurrent
+// versions keep the data key of the previous owner.
+func (b Bucket) Transfer(id, owner string) (*Descriptor, error) {
+	if owner == "" {
+		return nil, ErrMustIncludeOwnerAndName
+	}
+	src, err := b.GetMeta(id)
+	if err != nil {
+		return nil, err
+	}
+	if !b.needsReencryption(src, owner) {
+		return b.move(id, func(txn storeTxn, cur, meta *Metadata) error {
+			meta.set(MetaKeyOwner, owner)
+			return nil
+		})
+	}
+	blob, stat, err := b.reencrypt(src, owner)
+	if err != nil {
+		return nil, err
+	}
+	var orphans []string
+	d, err := b.move(id, func(txn storeTxn, cur, meta *Metadata) error {
+		if blobOf(cur) != blobOf(src) {
+			return fmt.Errorf("%w: payload of %s has been replaced during the transfer", ErrPreconditionFailed, id)
+		}
+		meta.set(MetaKeyOwner, owner)
+		meta.set(metaKeyBlob, blob)
+		stat.stamp(meta)
+		isOrphan, err := releaseBlob(txn, cur)
+		if err != nil {
+			return err
+		}
+		if isOrphan {
+			orphans = append(orphans, blobOf(cur))
+		}
+		return txn.Delete(intentKey(blob))
+	})
+	if err != nil {
+		return nil, b.rollback(err, blob)
+	}
+	return d, b.resolveIntents(orphans...)
+}
+
+// move applies fn to the metadata of the object and swaps
+// the name entry and the usage of the object in one transaction.
+// An expired object holding the new name is removed.
+func (b Bucket) move(id string, fn func(txn storeTxn, cur, meta *Metadata) error) (*Descriptor, error) {
+	var (
+		meta    *Metadata
+		orphans []string
+	)
+	err := b.meta.Update(func(txn storeTxn) error {
+		cur, err := b.getMeta(txn, id)
+		if err != nil {
+			return err
+		}
+		meta = cur.clone()
+		if err := fn(txn, cur, meta); err != nil {
+			return err
+		}
+		name, owner := meta.Get(MetaKeyName), meta.Get(MetaKeyOwner)
+		if name == cur.Get(MetaKeyName) && owner == cur.Get(MetaKeyOwner) {
+			meta = cur
+			return nil
+		}
+		orphans, err = b.reclaimName(txn, name, owner)
+		if err != nil {
+			return err
+		}
+		_, err = b.getIDByNameTxn(txn, name, owner)
+		if err == nil {
+			return nameConflict(name, owner)
+		}
+		if !errors.Is(err, errKeyNotFound) {
+			return err
+		}
+		if err := txn.Delete(nameKey(cur.Get(MetaKeyName), cur.Get(MetaKeyOwner))); err != nil {
+			return err
+		}
+		if err := txn.Set(nameKey(name, owner), []byte(id)); err != nil {
+			return err
+		}
+		if err := b.moveUsage(txn, cur, meta); err != nil {
+			return err
+		}
+		meta.set(MetaKeyUpdatedAt, formatTime(time.Now()))
+		return b.replaceMeta(txn, cur, meta)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Descriptor{meta: meta}, b.resolveIntents(orphans...)
+}
+
+// moveUsage moves the usage of the object to the new owner
+// of meta. The new owner has to have enough quota left.
+func (b Bucket) moveUsage(txn storeTxn, cur, meta *Metadata) error {
+	from, to := cur.Get(MetaKeyOwner), meta.Get(MetaKeyOwner)
+	if from == to {
+		return nil
+	}
+	u, err := b.objectUsage(txn, cur)
+	if err != nil {
+		return err
+	}
+	if err := refund(txn, from, u); err != nil {
+		return err
+	}
+	return b.charge(txn, to, u)
+}
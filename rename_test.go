@@ -0,0 +1,69 @@
+package objst
+
+import (
+	"testing"
+)
+
+func TestRename(t *testing.T) {
+	objs := tEnv.nObj(2)
+	objs[1].meta.set(MetaKeyOwner, objs[0].Owner())
+	if err := tEnv.b.BatchCreate(objs); err != nil {
+		t.Error(err)
+		return
+	}
+	name := tEnv.name()
+	d, err := tEnv.b.Rename(objs[0].ID(), name)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if d.ID() != objs[0].ID() || d.Name() != name {
+		t.Fatalf("object is not renamed. Got: %+v", d.ToModel())
+	}
+	o, err := tEnv.b.GetByName(name, objs[0].Owner())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if o.ID() != objs[0].ID() {
+		t.Fatalf("new name should reference the object")
+	}
+	if _, err := tEnv.b.GetByName(objs[0].Name(), objs[0].Owner()); err == nil {
+		t.Fatalf("old name should be released")
+	}
+	if _, err := tEnv.b.Rename(objs[1].ID(), name); err == nil {
+		t.Fatalf("rename should detect the name conflict")
+	}
+	if _, err := tEnv.b.Rename(objs[1].ID(), "invalid name"); err == nil {
+		t.Fatalf("rename should validate the name")
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	o := tEnv.obj()
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	owner := tEnv.owner()
+	if _, err := tEnv.b.Transfer(o.ID(), owner); err != nil {
+		t.Error(err)
+		return
+	}
+	objs, err := tEnv.b.Execute(NewQuery().Owner(owner))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(objs) != 1 || objs[0].ID() != o.ID() {
+		t.Fatalf("object should be found for the new owner. Got: %d objects", len(objs))
+	}
+	objs, err = tEnv.b.Execute(NewQuery().Owner(o.Owner()))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(objs) != 0 {
+		t.Fatalf("object should not be found for the old owner. Got: %d objects", len(objs))
+	}
+}
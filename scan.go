@@ -0,0 +1,335 @@
+package objst
+
+import (
+	"bytes"
+	"context"
+	"strings"
+)
+
+// Scanner iterates the objects matching a query one by one in
+// ascending order of their ids. All objects are read from the
+// same snapshot of the bucket so modifications after the scan
+// has started are not visible. Only the current object is held
+// in memory which allows to process any number of objects.
+// The scanner has to be closed to release the snapshot.
+type Scanner struct {
+	b     Bucket
+	ctx   context.Context
+	q     *Query
+	cond  Cond
+	txn   storeTxn
+	after *cursor
+	// ids yields the candidates in ascending order. Only the
+	// iterators of the snapshot are kept open which are
+	// advanced while scanning.
+	ids idStream
+
+	n    int
+	meta *Metadata
+	err  error
+}
+
+// Scan returns a scanner iterating the objects matching the
+// query. The limit and cursor of the query are respected but
+// the objects can only be sorted by id in ascending order.
+func (b Bucket) Scan(q *Query) (*Scanner, error) {
+	return b.ScanContext(context.Background(), q)
+}
+
+// ScanContext returns a scanner like Scan which stops
+// with the error of ctx once ctx is done.
+func (b Bucket) ScanContext(ctx context.Context, q *Query) (*Scanner, error) {
+	if err := q.isValid(); err != nil {
+		return nil, err
+	}
+	if q.sortKey != "" || q.order == Desc {
+		return nil, ErrUnsortedScan
+	}
+	after, err := decodeCursor(q)
+	if err != nil {
+		return nil, err
+	}
+	cond := q.condition()
+	if err := validateCond(cond); err != nil {
+		return nil, err
+	}
+	s := &Scanner{
+		b:     b,
+		ctx:   ctx,
+		q:     q,
+		cond:  cond,
+		txn:   b.meta.NewTxn(false),
+		after: after,
+	}
+	start := ""
+	if after != nil {
+		start = after.ID
+	}
+	ids, ok := b.streamCandidates(s.txn, cond, start, Asc)
+	if !ok {
+		ids = newKeyStream(s.txn, prefixMeta, start, Asc)
+	}
+	s.ids = ids
+	return s, nil
+}
+
+// Next advances the scanner to the next matching object and
+// reports if there is one. Err has to be checked afterwards
+// to distinguish the end of the scan from a failure.
+func (s *Scanner) Next() bool {
+	s.meta = nil
+	if s.err != nil || s.txn == nil {
+		return false
+	}
+	if s.q.limit > 0 && s.n == s.q.limit {
+		return false
+	}
+	for {
+		if err := s.ctx.Err(); err != nil {
+			s.err = err
+			return false
+		}
+		id, ok := s.ids.next()
+		if !ok {
+			return false
+		}
+		if s.after != nil && id <= s.after.ID {
+			continue
+		}
+		meta, ok, err := s.b.loadMatch(s.txn, s.cond, id)
+		if err != nil {
+			s.err = err
+			return false
+		}
+		if ok {
+			s.meta = meta
+			s.n++
+			return true
+		}
+	}
+}
+
+// Descriptor returns the descriptor of the current
+// object or nil if there is no current object.
+func (s *Scanner) Descriptor() *Descriptor {
+	if s.meta == nil {
+		return nil
+	}
+	return &Descriptor{meta: s.meta}
+}
+
+// Object returns the current object including its payload. The
+// payload of an object which has been removed after the scan
+// has started might not be available anymore. If there is
+// no current object nil is returned.
+func (s *Scanner) Object() (*Object, error) {
+	if s.meta == nil {
+		return nil, nil
+	}
+	return s.b.composeObject(s.meta)
+}
+
+// Cursor returns the cursor to continue the scan or fetch the
+// next page after the current object using the same query.
+// If there is no current object an empty cursor is returned.
+func (s *Scanner) Cursor() string {
+	if s.meta == nil {
+		return ""
+	}
+	return s.q.cursorOf(s.meta)
+}
+
+// Err returns the error which stopped the scan.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// Close releases the snapshot of the scanner. It's
+// safe to call Close multiple times.
+func (s *Scanner) Close() error {
+	if s.txn == nil {
+		return nil
+	}
+	s.ids.close()
+	s.txn.Discard()
+	s.txn = nil
+	return nil
+}
+
+// idStream yields the ids of candidates in ascending
+// or descending order.
+type idStream interface {
+	next() (string, bool)
+	close()
+}
+
+// streamCandidates returns a stream of the candidates of the
+// condition in the order o continuing after the id start. The
+// returned bool reports if the condition can be streamed using
+// exact lookups. Lookups of prefixes and patterns are not
+// ordered by id.
+func (b Bucket) streamCandidates(txn storeTxn, c Cond, start string, o order) (idStream, bool) {
+	if r, ok := b.rank(c); !ok || r > rankExact {
+		return nil, false
+	}
+	switch c := c.(type) {
+	case eqCond:
+		return b.streamValue(txn, c.k, c.v, start, o), true
+	case inCond:
+		streams := make([]idStream, 0, len(c.vs))
+		for _, v := range c.vs {
+			streams = append(streams, b.streamValue(txn, c.k, v, start, o))
+		}
+		return &mergeStream{streams: streams, order: o}, true
+	case matchCond:
+		literal, _ := c.re.LiteralPrefix()
+		return b.streamValue(txn, c.k, literal, start, o), true
+	case allCond:
+		// the candidates of one condition are sufficient
+		// because every object has to fulfill all of them.
+		var best Cond
+		bestRank := 0
+		for _, cond := range c.conds {
+			r, ok := b.rank(cond)
+			if ok && (best == nil || r < bestRank) {
+				best, bestRank = cond, r
+			}
+		}
+		return b.streamCandidates(txn, best, start, o)
+	case anyCond:
+		streams := make([]idStream, 0, len(c.conds))
+		for _, cond := range c.conds {
+			s, _ := b.streamCandidates(txn, cond, start, o)
+			streams = append(streams, s)
+		}
+		return &mergeStream{streams: streams, order: o}, true
+	}
+	return nil, false
+}
+
+// streamValue returns a stream of the ids of all
+// objects whose value of k is equal to v.
+func (b Bucket) streamValue(txn storeTxn, k MetaKey, v, start string, o order) idStream {
+	if k != MetaKeyID {
+		return newKeyStream(txn, prefixIndex+k.String()+indexSep+v+indexSep, start, o)
+	}
+	if start != "" && !isBefore(o, start, v) {
+		return &sliceStream{}
+	}
+	return &sliceStream{ids: []string{v}}
+}
+
+// keyStream yields the ids of the keys with the prefix in the
+// given order. The id is the last part of the key following the
+// prefix. The stream continues after the key with the suffix
+// start. An empty start begins with the first key.
+type keyStream struct {
+	it      storeIterator
+	prefix  string
+	start   string
+	started bool
+}
+
+func newKeyStream(txn storeTxn, prefix, start string, o order) *keyStream {
+	var it storeIterator
+	if o == Desc {
+		it = txn.IterateReverse([]byte(prefix), true)
+	} else {
+		it = txn.Iterate([]byte(prefix), true)
+	}
+	return &keyStream{
+		it:     it,
+		prefix: prefix,
+		start:  start,
+	}
+}
+
+func (s *keyStream) next() (string, bool) {
+	switch {
+	case s.started:
+		s.it.Next()
+	case s.start == "":
+		s.it.Rewind()
+	default:
+		start := []byte(s.prefix + s.start)
+		s.it.Seek(start)
+		if s.it.Valid() && bytes.Equal(s.it.Key(), start) {
+			s.it.Next()
+		}
+	}
+	s.started = true
+	if !s.it.Valid() {
+		return "", false
+	}
+	rest := strings.TrimPrefix(string(s.it.Key()), s.prefix)
+	return rest[strings.LastIndex(rest, indexSep)+1:], true
+}
+
+func (s *keyStream) close() {
+	s.it.Close()
+}
+
+// sliceStream yields the sorted ids of the slice.
+type sliceStream struct {
+	ids []string
+}
+
+func (s *sliceStream) next() (string, bool) {
+	if len(s.ids) == 0 {
+		return "", false
+	}
+	id := s.ids[0]
+	s.ids = s.ids[1:]
+	return id, true
+}
+
+func (s *sliceStream) close() {}
+
+// mergeStream merges the streams of the same order
+// into one stream without duplicate ids.
+type mergeStream struct {
+	streams []idStream
+	order   order
+	heads   []string
+	valid   []bool
+	last    string
+}
+
+func (m *mergeStream) next() (string, bool) {
+	if m.heads == nil {
+		m.heads = make([]string, len(m.streams))
+		m.valid = make([]bool, len(m.streams))
+		for i, s := range m.streams {
+			m.heads[i], m.valid[i] = s.next()
+		}
+	} else {
+		// advance all streams which yielded the last id
+		for i, s := range m.streams {
+			if m.valid[i] && m.heads[i] == m.last {
+				m.heads[i], m.valid[i] = s.next()
+			}
+		}
+	}
+	first, ok := "", false
+	for i, head := range m.heads {
+		if m.valid[i] && (!ok || isBefore(m.order, head, first)) {
+			first, ok = head, true
+		}
+	}
+	m.last = first
+	return first, ok
+}
+
+// isBefore reports if the id a comes before b in the order o.
+func isBefore(o order, a, b string) bool {
+	if o == Desc {
+		return a > b
+	}
+	return a < b
+}
+
+func (m *mergeStream) close() {
+	for _, s := range m.streams {
+		s.close()
+	}
+}
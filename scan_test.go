@@ -0,0 +1,150 @@
+package objst
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestScan(t *testing.T) {
+	owner := tEnv.owner()
+	objs := tEnv.nObj(5)
+	payloads := make(map[string][]byte, len(objs))
+	for _, o := range objs {
+		o.meta.set(MetaKeyOwner, owner)
+		o.SetMetaKey("scan", owner)
+		if err := tEnv.b.Create(o); err != nil {
+			t.Error(err)
+			return
+		}
+		payloads[o.ID()] = o.Payload()
+	}
+	tests := []struct {
+		name string
+		q    *Query
+	}{
+		{
+			name: "indexed",
+			q:    NewQuery().Owner(owner),
+		},
+		{
+			name: "merged",
+			q:    NewQuery().Where(In(MetaKeyOwner, owner, tEnv.owner())),
+		},
+		{
+			name: "full scan",
+			q:    NewQuery().Where(Eq("scan", owner)),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := tEnv.b.Scan(tc.q)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer s.Close()
+			// objects created after the scan has
+			// started are not part of the snapshot.
+			later := tEnv.obj()
+			later.meta.set(MetaKeyOwner, owner)
+			later.SetMetaKey("scan", owner)
+			if err := tEnv.b.Create(later); err != nil {
+				t.Error(err)
+				return
+			}
+			defer tEnv.b.DeleteByID(later.ID())
+			prev := ""
+			n := 0
+			for s.Next() {
+				obj, err := s.Object()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if obj.ID() <= prev {
+					t.Fatalf("objects are not ordered by id. Got: %s after %s", obj.ID(), prev)
+				}
+				if !bytes.Equal(obj.Payload(), payloads[obj.ID()]) {
+					t.Fatalf("payload is not correct. Got: %s. Expected: %s", obj.Payload(), payloads[obj.ID()])
+				}
+				prev = obj.ID()
+				n++
+			}
+			if err := s.Err(); err != nil {
+				t.Error(err)
+				return
+			}
+			if n != len(objs) {
+				t.Fatalf("expected %d objects. Got: %d", len(objs), n)
+			}
+			if obj, err := s.Object(); obj != nil || err != nil || s.Cursor() != "" {
+				t.Fatalf("finished scan should not have a current object")
+			}
+		})
+	}
+}
+
+func TestScanCursor(t *testing.T) {
+	owner := tEnv.owner()
+	for _, o := range tEnv.nObj(3) {
+		o.meta.set(MetaKeyOwner, owner)
+		if err := tEnv.b.Create(o); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	cursor := ""
+	ids := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		s, err := tEnv.b.Scan(NewQuery().Owner(owner).Limit(2).Cursor(cursor))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		for s.Next() {
+			ids[s.Descriptor().ID()] = true
+			cursor = s.Cursor()
+		}
+		if err := s.Err(); err != nil {
+			t.Error(err)
+			return
+		}
+		s.Close()
+	}
+	if len(ids) != 3 {
+		t.Fatalf("scan should continue after the cursor. Got: %d objects", len(ids))
+	}
+}
+
+func TestScanInvalid(t *testing.T) {
+	if _, err := tEnv.b.Scan(NewQuery().Owner("invalid")); !errors.Is(err, ErrInvalidUUID) {
+		t.Fatalf("invalid query should be rejected. Got: %v", err)
+	}
+	if _, err := tEnv.b.Scan(NewQuery().Owner(uuid.NewString()).SortBy(MetaKeyName, Asc)); !errors.Is(err, ErrUnsortedScan) {
+		t.Fatalf("sorted scan should be rejected. Got: %v", err)
+	}
+	o := tEnv.obj()
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := tEnv.b.ScanContext(ctx, NewQuery().Owner(o.Owner()))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer s.Close()
+	cancel()
+	if s.Next() {
+		t.Fatalf("canceled scan should not yield objects")
+	}
+	if !errors.Is(s.Err(), context.Canceled) {
+		t.Fatalf("scan should be canceled. Got: %v", s.Err())
+	}
+}
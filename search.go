@@ -0,0 +1,323 @@
+package objst
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// Page is a page of the results of a query.
+type Page[T any] struct {
+	Items []T
+	// Cursor is used to fetch the next page by setting
+	// it on the query. It's empty on the last page.
+	Cursor string
+}
+
+// cursor is the sort position of the last
+// item of a page and the sorting it belongs to.
+type cursor struct {
+	Key   MetaKey `json:"k,omitempty"`
+	Order order   `json:"o"`
+	Value string  `json:"v"`
+	ID    string  `json:"id"`
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the cursor of the query or
+// nil if the first page of the query is requested.
+func decodeCursor(q *Query) (*cursor, error) {
+	if q.cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(q.cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	c := cursor{}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Key != q.sortKey || c.Order != q.order {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// compareValues compares two meta data values. Numbers and
+// timestamps in the RFC3339 format are compared by their
+// value. All other values are compared lexicographically.
+func compareValues(a, b string) int {
+	if x, err := strconv.ParseFloat(a, 64); err == nil {
+		if y, err := strconv.ParseFloat(b, 64); err == nil {
+			return compareOrdered(x, y)
+		}
+	}
+	if x, err := time.Parse(time.RFC3339Nano, a); err == nil {
+		if y, err := time.Parse(time.RFC3339Nano, b); err == nil {
+			return x.Compare(y)
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+func compareOrdered(a, b float64) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// sortValue returns the value the object is sorted by.
+func (q *Query) sortValue(meta *Metadata) string {
+	if q.sortKey == "" {
+		return meta.Get(MetaKeyID)
+	}
+	return meta.Get(q.sortKey)
+}
+
+// position compares the positions of two objects
+// identified by their sort value and id.
+func (q *Query) position(v1, id1, v2, id2 string) int {
+	c := compareValues(v1, v2)
+	if c == 0 {
+		c = strings.Compare(id1, id2)
+	}
+	if q.order == Desc {
+		return -c
+	}
+	return c
+}
+
+// isAfter reports if the object is positioned after the cursor.
+func (q *Query) isAfter(meta *Metadata, c *cursor) bool {
+	if c == nil {
+		return true
+	}
+	return q.position(q.sortValue(meta), meta.Get(MetaKeyID), c.Value, c.ID) > 0
+}
+
+func (q *Query) cursorOf(meta *Metadata) string {
+	return encodeCursor(cursor{
+		Key:   q.sortKey,
+		Order: q.order,
+		Value: q.sortValue(meta),
+		ID:    meta.Get(MetaKeyID),
+	})
+}
+
+// findPage returns the metadata of the objects of the
+// requested page and the cursor of the next page.
+func (b Bucket) findPage(ctx context.Context, q *Query) ([]*Metadata, string, error) {
+	after, err := decodeCursor(q)
+	if err != nil {
+		return nil, "", err
+	}
+	var (
+		metas []*Metadata
+		next  string
+	)
+	// the condition is created once to compile
+	// the patterns only once for all objects.
+	cond := q.condition()
+	if err := validateCond(cond); err != nil {
+		return nil, "", err
+	}
+	err = b.meta.View(func(txn storeTxn) error {
+		ids, err := b.orderedIDs(txn, q, cond, after)
+		if err != nil {
+			return err
+		}
+		if ids != nil {
+			// only the metadata up to the end
+			// of the page has to be loaded.
+			defer ids.close()
+			metas, next, err = b.streamPage(ctx, txn, q, cond, ids)
+			return err
+		}
+		candidates, ok, err := b.candidates(txn, cond)
+		if err != nil {
+			return err
+		}
+		var matches []*Metadata
+		if ok {
+			matches, err = b.loadMatches(ctx, txn, cond, candidates)
+		} else {
+			matches, err = b.scanMatches(ctx, txn, cond)
+		}
+		if err != nil {
+			return err
+		}
+		metas, next = paginate(q, matches, after)
+		return nil
+	})
+	return metas, next, err
+}
+
+// orderedIDs returns a stream of the ids of the candidates of the
+// condition in the order of the query starting after the cursor.
+// The stream is nil if the metadata of the candidates has to be
+// loaded to order them.
+func (b Bucket) orderedIDs(txn storeTxn, q *Query, cond Cond, after *cursor) (idStream, error) {
+	if q.sortKey == "" || q.sortKey == MetaKeyID {
+		start := ""
+		if after != nil {
+			start = after.ID
+		}
+		if ids, ok := b.streamCandidates(txn, cond, start, q.order); ok {
+			return ids, nil
+		}
+		candidates, ok, err := b.candidates(txn, cond)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &sliceStream{ids: sortIDs(q, candidates, after)}, nil
+		}
+		return newKeyStream(txn, prefixMeta, start, q.order), nil
+	}
+	if _, isSortable := sortKeys[q.sortKey]; !isSortable {
+		return nil, nil
+	}
+	if _, ok := b.rank(cond); ok {
+		// the candidates are a subset of all objects which is
+		// cheaper to sort than walking the sort entries of all
+		// objects of the bucket.
+		return nil, nil
+	}
+	start := ""
+	if after != nil {
+		pos, isValid := sortPosition(q.sortKey, after.Value, after.ID)
+		if !isValid {
+			return nil, ErrInvalidCursor
+		}
+		start = pos
+	}
+	return newKeyStream(txn, sortPrefix(q.sortKey), start, q.order), nil
+}
+
+// sortIDs sorts the ids in the order of the query
+// and removes the ids up to the cursor.
+func sortIDs(q *Query, ids []string, after *cursor) []string {
+	slices.SortFunc(ids, func(a, b string) bool {
+		return q.position(a, a, b, b) < 0
+	})
+	start := 0
+	for after != nil && start < len(ids) && q.position(ids[start], ids[start], after.Value, after.ID) <= 0 {
+		start++
+	}
+	return ids[start:]
+}
+
+// streamPage loads the ordered ids until the page is full.
+func (b Bucket) streamPage(ctx context.Context, txn storeTxn, q *Query, cond Cond, ids idStream) ([]*Metadata, string, error) {
+	metas := make([]*Metadata, 0)
+	for id, ok := ids.next(); ok; id, ok = ids.next() {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		meta, ok, err := b.loadMatch(txn, cond, id)
+		if err != nil {
+			return nil, "", err
+		}
+		if !ok {
+			continue
+		}
+		if q.limit > 0 && len(metas) == q.limit {
+			// another match exists so the page is not the last one.
+			return metas, q.cursorOf(metas[len(metas)-1]), nil
+		}
+		metas = append(metas, meta)
+	}
+	return metas, "", nil
+}
+
+// paginate sorts the matches and returns the requested page.
+func paginate(q *Query, matches []*Metadata, after *cursor) ([]*Metadata, string) {
+	slices.SortFunc(matches, func(a, b *Metadata) bool {
+		return q.position(q.sortValue(a), a.Get(MetaKeyID), q.sortValue(b), b.Get(MetaKeyID)) < 0
+	})
+	start := 0
+	for start < len(matches) && !q.isAfter(matches[start], after) {
+		start++
+	}
+	matches = matches[start:]
+	if q.limit == 0 || len(matches) <= q.limit {
+		return matches, ""
+	}
+	page := matches[:q.limit]
+	return page, q.cursorOf(page[len(page)-1])
+}
+
+// loadMatch loads the metadata of the object and
+// reports if the object fulfills the condition.
+func (b Bucket) loadMatch(txn storeTxn, cond Cond, id string) (*Metadata, bool, error) {
+	meta, err := b.getMeta(txn, id)
+	if errors.Is(err, errKeyNotFound) {
+		// index entries of concurrently removed objects
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return meta, cond.match(meta) && !isExpired(meta, time.Now()), nil
+}
+
+// loadMatches loads the metadata of all
+// candidates which fulfill the condition.
+func (b Bucket) loadMatches(ctx context.Context, txn storeTxn, cond Cond, ids []string) ([]*Metadata, error) {
+	metas := make([]*Metadata, 0, len(ids))
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		meta, ok, err := b.loadMatch(txn, cond, id)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			metas = append(metas, meta)
+		}
+	}
+	return metas, nil
+}
+
+// scanMatches checks the condition for every object
+// and stops with the error of ctx once ctx is done.
+func (b Bucket) scanMatches(ctx context.Context, txn storeTxn, cond Cond) ([]*Metadata, error) {
+	metas := make([]*Metadata, 0)
+	now := time.Now()
+	it := txn.Iterate([]byte(prefixMeta), false)
+	defer it.Close()
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		val, err := it.Value()
+		if err != nil {
+			return nil, err
+		}
+		meta := NewMetadata()
+		if err := meta.Unmarshal(val); err != nil {
+			return nil, err
+		}
+		if cond.match(meta) && !isExpired(meta, now) {
+			metas = append(metas, meta)
+		}
+	}
+	return metas, nil
+}
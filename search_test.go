@@ -0,0 +1,139 @@
+package objst
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestGetPage(t *testing.T) {
+	const (
+		n     int     = 7
+		rank  MetaKey = "rank"
+		group MetaKey = "group"
+	)
+	owner := tEnv.owner()
+	for i := 0; i < n; i++ {
+		o, _ := NewObject(tEnv.name(), owner)
+		o.Write(tEnv.payload(n - i))
+		o.SetMetaKey(rank, strconv.Itoa(i))
+		o.SetMetaKey(group, owner)
+		if err := tEnv.b.Create(o); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	tests := []struct {
+		name string
+		q    func() *Query
+	}{
+		{
+			name: "sorted by id",
+			q: func() *Query {
+				return NewQuery().Owner(owner).Limit(3)
+			},
+		},
+		{
+			name: "sorted by id descending",
+			q: func() *Query {
+				return NewQuery().Owner(owner).Limit(3).SortBy(MetaKeyID, Desc)
+			},
+		},
+		{
+			name: "sorted by id of merged lookups",
+			q: func() *Query {
+				return NewQuery().Where(In(MetaKeyOwner, owner, tEnv.owner())).Limit(3).SortBy(MetaKeyID, Desc)
+			},
+		},
+		{
+			name: "sorted by id without index",
+			q: func() *Query {
+				return NewQuery().Where(Eq(group, owner)).Limit(3).SortBy(MetaKeyID, Desc)
+			},
+		},
+		{
+			name: "sorted by numeric value",
+			q: func() *Query {
+				return NewQuery().Owner(owner).Limit(3).SortBy(rank, Desc)
+			},
+		},
+		{
+			name: "sorted by size",
+			q: func() *Query {
+				return NewQuery().Owner(owner).Limit(3).SortBy(MetaKeySize, Asc)
+			},
+		},
+		{
+			name: "sorted by name",
+			q: func() *Query {
+				return NewQuery().Where(Eq(group, owner)).Limit(3).SortBy(MetaKeyName, Desc)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := test.q()
+			var (
+				pages int
+				seen  = make(map[string]bool)
+				metas = make([]*Metadata, 0, n)
+			)
+			for {
+				page, err := tEnv.b.GetPage(q)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				pages++
+				for _, obj := range page.Items {
+					seen[obj.ID()] = true
+					metas = append(metas, obj.meta)
+				}
+				if page.Cursor == "" {
+					break
+				}
+				q = test.q().Cursor(page.Cursor)
+			}
+			if pages != 3 || len(seen) != n {
+				t.Fatalf("pages are not as expected. Got: %d pages with %d objects. Expected: 3 pages with %d objects", pages, len(seen), n)
+			}
+			for i := 1; i < len(metas); i++ {
+				prev, cur := metas[i-1], metas[i]
+				if q.position(q.sortValue(prev), prev.Get(MetaKeyID), q.sortValue(cur), cur.Get(MetaKeyID)) >= 0 {
+					t.Fatalf("objects are not sorted. Got: %s after %s", q.sortValue(cur), q.sortValue(prev))
+				}
+			}
+		})
+	}
+}
+
+func TestGetPageInvalidCursor(t *testing.T) {
+	owner := tEnv.owner()
+	for i := 0; i < 2; i++ {
+		o := tEnv.obj()
+		o.meta.set(MetaKeyOwner, owner)
+		if err := tEnv.b.Create(o); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	page, err := tEnv.b.GetPage(NewQuery().Owner(owner).Limit(1))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = tEnv.b.GetPage(NewQuery().Owner(owner).Limit(1).SortBy(MetaKeyName, Asc).Cursor(page.Cursor))
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("cursor of another sorting should be invalid. Got: %v", err)
+	}
+}
+
+func TestGetPageInvalidQuery(t *testing.T) {
+	q := NewQuery().Owner("invalid").Limit(1)
+	if _, err := tEnv.b.GetPage(q); !errors.Is(err, ErrInvalidUUID) {
+		t.Fatalf("invalid query should be rejected. Got: %v", err)
+	}
+	if _, err := tEnv.b.List(q); !errors.Is(err, ErrInvalidUUID) {
+		t.Fatalf("invalid query should be rejected. Got: %v", err)
+	}
+}
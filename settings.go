@@ -0,0 +1,154 @@
+package objst
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"golang.org/x/exp/slices"
+)
+
+// settingsKey is the key of the persisted settings.
+const settingsKey = "sys/settings"
+
+// settings are the persisted configuration of a bucket
+// shared between all copies of the bucket.
+type settings struct {
+	mu sync.RWMutex
+
+	// Versioning keeps the previous versions of an
+	// object if an object with the same name and
+	// owner is created.
+	Versioning bool `json:"versioning"`
+
+	// Deduplication stores equal payloads only
+	// once by addressing them by their checksum.
+	Deduplication bool `json:"deduplication"`
+
+	// Compression decides which payloads
+	// are compressed.
+	Compression CompressionPolicy `json:"compression"`
+
+	// Lifecycle are the rules removing
+	// objects and versions over time.
+	Lifecycle []LifecycleRule `json:"lifecycle"`
+
+	// DefaultQuota is the quota of all owners
+	// which don't have a quota of their own.
+	DefaultQuota Quota `json:"defaultQuota"`
+}
+
+// view calls fn with the settings locked for reading.
+func (s *settings) view(fn func(s *settings)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn(s)
+}
+
+func (s *settings) isVersioned() bool {
+	var versioned bool
+	s.view(func(s *settings) {
+		versioned = s.Versioning
+	})
+	return versioned
+}
+
+func (s *settings) isDeduplicated() bool {
+	var deduplicated bool
+	s.view(func(s *settings) {
+		deduplicated = s.Deduplication
+	})
+	return deduplicated
+}
+
+func (s *settings) compression() CompressionPolicy {
+	var p CompressionPolicy
+	s.view(func(s *settings) {
+		p = s.Compression
+	})
+	return p
+}
+
+func (s *settings) lifecycle() []LifecycleRule {
+	var rules []LifecycleRule
+	s.view(func(s *settings) {
+		rules = slices.Clone(s.Lifecycle)
+	})
+	return rules
+}
+
+func (s *settings) defaultQuota() Quota {
+	var q Quota
+	s.view(func(s *settings) {
+		q = s.DefaultQuota
+	})
+	return q
+}
+
+// loadSettings loads the persisted settings of the bucket.
+func (b Bucket) loadSettings() error {
+	return b.meta.View(func(txn storeTxn) error {
+		val, err := txn.Get([]byte(settingsKey))
+		if errors.Is(err, errKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(val, b.settings)
+	})
+}
+
+// updateSettings applies fn to the settings and persists
+// them. The settings are not changed if persisting fails.
+func (b Bucket) updateSettings(fn func(s *settings)) error {
+	b.settings.mu.Lock()
+	defer b.settings.mu.Unlock()
+	data, err := json.Marshal(b.settings)
+	if err != nil {
+		return err
+	}
+	fn(b.settings)
+	err = b.meta.Update(func(txn storeTxn) error {
+		data, err := json.Marshal(b.settings)
+		if err != nil {
+			return err
+		}
+		return txn.Set([]byte(settingsKey), data)
+	})
+	if err != nil {
+		// restore the previous settings
+		return errors.Join(err, json.Unmarshal(data, b.settings))
+	}
+	return nil
+}
+
+// SetVersioning enables or disables the versioning of the bucket.
+// If enabled, creating an object with the name of an existing
+// object of the same owner will create a new version of the
+// existing object instead of failing. The setting is persisted.
+func (b Bucket) SetVersioning(enabled bool) error {
+	return b.updateSettings(func(s *settings) {
+		s.Versioning = enabled
+	})
+}
+
+// IsVersioned reports if the versioning of the bucket is enabled.
+func (b Bucket) IsVersioned() bool {
+	return b.settings.isVersioned()
+}
+
+// SetDeduplication enables or disables the deduplication of payloads.
+// If enabled, payloads are addressed by their checksum and objects with
+// an equal payload share the stored payload. Payloads stored before the
+// deduplication was enabled are not deduplicated. The setting is persisted.
+func (b Bucket) SetDeduplication(enabled bool) error {
+	return b.updateSettings(func(s *settings) {
+		s.Deduplication = enabled
+	})
+}
+
+// IsDeduplicated reports if the deduplication of payloads is enabled.
+func (b Bucket) IsDeduplicated() bool {
+	return b.settings.isDeduplicated()
+}
@@ -0,0 +1,92 @@
+package objst
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// prefixSort is the prefix of the sort entries in the format
+// sort/<key>\x00<sortable value>\x00<id>. The entries of a key
+// are ordered like the values are compared by compareValues.
+const prefixSort = "sort/"
+
+// sortKeys are the meta keys which can be sorted using the sort
+// entries. The values are encoded to keep the entries in the
+// order of the values.
+var sortKeys = map[MetaKey]func(v string) (string, bool){
+	MetaKeyName:      sortableString,
+	MetaKeySize:      sortableNumber,
+	MetaKeyCreatedAt: sortableTime,
+	MetaKeyUpdatedAt: sortableTime,
+}
+
+func sortableString(v string) (string, bool) {
+	return v, true
+}
+
+func sortableNumber(v string) (string, bool) {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return "", false
+	}
+	return sortableInt(n), true
+}
+
+func sortableTime(v string) (string, bool) {
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return "", false
+	}
+	return sortableInt(t.UnixNano()), true
+}
+
+// sortableInt encodes n with a fixed length and a
+// flipped sign bit to order negative numbers first.
+func sortableInt(n int64) string {
+	return fmt.Sprintf("%016x", uint64(n)^(1<<63))
+}
+
+func sortPrefix(k MetaKey) string {
+	return prefixSort + k.String() + indexSep
+}
+
+// sortPosition returns the position of the object with the
+// value v of k and the given id within the sort entries of k.
+func sortPosition(k MetaKey, v, id string) (string, bool) {
+	sv, ok := sortKeys[k](v)
+	if !ok {
+		return "", false
+	}
+	return sv + indexSep + id, true
+}
+
+// setSortEntries inserts the sort entries of the object.
+func setSortEntries(txn storeTxn, meta *Metadata) error {
+	id := meta.Get(MetaKeyID)
+	for k := range sortKeys {
+		pos, ok := sortPosition(k, meta.Get(k), id)
+		if !ok {
+			continue
+		}
+		if err := txn.Set([]byte(sortPrefix(k)+pos), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteSortEntries removes the sort entries of the object.
+func deleteSortEntries(txn storeTxn, meta *Metadata) error {
+	id := meta.Get(MetaKeyID)
+	for k := range sortKeys {
+		pos, ok := sortPosition(k, meta.Get(k), id)
+		if !ok {
+			continue
+		}
+		if err := txn.Delete([]byte(sortPrefix(k) + pos)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package objst
+
+import "errors"
+
+var (
+	// errKeyNotFound is returned by a store
+	// if the requested key doesn't exist.
+	errKeyNotFound = errors.New("key not found")
+	// errTxnTooBig is returned by a store if a
+	// transaction exceeds the limits of the store.
+	errTxnTooBig = errors.New("transaction is too big")
+	// errConflict is returned by a store if a transaction
+	// read keys which were modified concurrently.
+	errConflict = errors.New("transaction conflict")
+)
+
+// store is a transactional key-value store. The bucket is
+// written against this interface to be independent of the
+// storage engine. Badger is the default implementation.
+type store interface {
+	// View runs fn in a read-only transaction.
+	View(fn func(txn storeTxn) error) error
+
+	// Update runs fn in a read-write transaction which
+	// is committed if fn doesn't return an error.
+	Update(fn func(txn storeTxn) error) error
+
+	// NewTxn starts a transaction which has to be
+	// committed or discarded by the caller.
+	NewTxn(update bool) storeTxn
+
+	// NewBatch returns a batch for large writes which don't
+	// have to be atomic e.g. the chunks of a payload.
+	NewBatch() storeBatch
+
+	Close() error
+}
+
+// storeTxn is a transaction of a store. Keys and values
+// returned by a transaction are owned by the caller.
+type storeTxn interface {
+	// Get returns the value of the key or an
+	// error wrapping errKeyNotFound.
+	Get(key []byte) ([]byte, error)
+
+	Set(key, val []byte) error
+
+	Delete(key []byte) error
+
+	// Iterate returns an iterator over all keys with the
+	// prefix in ascending order. If keysOnly is set the
+	// values might not be fetched until they are read.
+	Iterate(prefix []byte, keysOnly bool) storeIterator
+
+	// IterateReverse returns an iterator like Iterate
+	// which visits the keys in descending order.
+	IterateReverse(prefix []byte, keysOnly bool) storeIterator
+
+	Commit() error
+
+	Discard()
+}
+
+// storeIterator iterates the keys of a transaction.
+type storeIterator interface {
+	Rewind()
+	// Seek moves to the smallest key which is greater
+	// than or equal to key and has the prefix. Reverse
+	// iterators move to the largest key which is less
+	// than or equal to key.
+	Seek(key []byte)
+	Valid() bool
+	Next()
+	Key() []byte
+	Value() ([]byte, error)
+	Close()
+}
+
+// storeBatch collects writes and applies them in
+// possibly multiple transactions on Flush.
+type storeBatch interface {
+	Set(key, val []byte) error
+	Delete(key []byte) error
+	Flush() error
+	Cancel()
+}
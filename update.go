@@ -0,0 +1,173 @@
+package objst
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// etagOf returns the entity tag of the object. The tag
+// changes with every modification of the metadata or
+// payload of the object.
+func etagOf(meta *Metadata) string {
+	sum := sha256.Sum256([]byte(meta.Encode()))
+	return hex.EncodeToString(sum[:16])
+}
+
+// isMatching checks if the entity tag of the object
+// matches etag. An empty etag matches every object.
+func isMatching(meta *Metadata, etag string) error {
+	if etag == "" || etag == etagOf(meta) {
+		return nil
+	}
+	return fmt.Errorf("%w: object %s has been modified", ErrPreconditionFailed, meta.Get(MetaKeyID))
+}
+
+// UpdateMeta applies the patch to the user defined metadata of the
+// object. Keys with an empty value will be removed and system keys
+// are ignored. The content type can't be removed. If etag is not empty the object is only updated if
+// its current entity tag matches etag. The metadata is updated in
+// place even if the bucket is versioned.
+func (b Bucket) UpdateMeta(id string, patch map[MetaKey]string, etag string) (*Descriptor, error) {
+	var meta *Metadata
+	err := b.meta.Update(func(txn storeTxn) error {
+		cur, err := b.getMeta(txn, id)
+		if err != nil {
+			return err
+		}
+		if err := isMatching(cur, etag); err != nil {
+			return err
+		}
+		meta = cur.clone()
+		for k, v := range patch {
+			if v == "" {
+				meta.Del(k)
+				continue
+			}
+			meta.Set(k, v)
+		}
+		// the patched metadata has to be valid like
+		// the metadata of a newly created object.
+		if err := (Object{meta: meta}).isValidMeta(); err != nil {
+			return err
+		}
+		meta.set(MetaKeyUpdatedAt, formatTime(time.Now()))
+		return b.replaceMeta(txn, cur, meta)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Descriptor{meta: meta}, nil
+}
+
+// Replace replaces the payload of the object with the data read
+// from r keeping the id, name and metadata of the object. If the
+// bucket is versioned a new version of the object is created. If
+// etag is not empty the object is only replaced if its current
+// entity tag matches etag.
+func (b Bucket) Replace(id string, r io.Reader, etag string) (*Descriptor, error) {
+	// fail early without writing the payload
+	cur, err := b.GetMeta(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := isMatching(cur, etag); err != nil {
+		return nil, err
+	}
+	// every payload contains at least one byte
+	delta := Usage{Bytes: 1}
+	if !b.IsVersioned() {
+		delta.Bytes -= sizeOf(cur)
+	}
+	if err := b.checkQuota(cur.Get(MetaKeyOwner), delta); err != nil {
+		return nil, err
+	}
+	f, err := b.formatFor(cur)
+	if err != nil {
+		return nil, err
+	}
+	blob := uuid.NewString()
+	if err := b.insertIntents(blob); err != nil {
+		return nil, err
+	}
+	stat, err := b.insertPayload(blob, r, f)
+	if err != nil {
+		return nil, b.rollback(err, blob)
+	}
+	if stat.size == 0 {
+		return nil, b.rollback(ErrEmptyPayload, blob)
+	}
+	var (
+		meta   *Metadata
+		orphan string
+	)
+	err = b.meta.Update(func(txn storeTxn) error {
+		cur, err := b.getMeta(txn, id)
+		if err != nil {
+			return err
+		}
+		if err := isMatching(cur, etag); err != nil {
+			return err
+		}
+		meta = cur.clone()
+		meta.set(metaKeyBlob, blob)
+		stat.stamp(meta)
+		if err := b.dedupe(txn, meta); err != nil {
+			return err
+		}
+		// the replaced payload is only freed if the bucket isn't versioned
+		delta := Usage{Bytes: sizeOf(meta)}
+		if b.IsVersioned() {
+			if err := b.archiveVersion(txn, id, meta); err != nil {
+				return err
+			}
+		} else {
+			delta.Bytes -= sizeOf(cur)
+			isOrphan, err := releaseBlob(txn, cur)
+			if err != nil {
+				return err
+			}
+			if isOrphan {
+				orphan = blobOf(cur)
+			}
+		}
+		if err := b.charge(txn, cur.Get(MetaKeyOwner), delta); err != nil {
+			return err
+		}
+		if err := b.replaceMeta(txn, cur, meta); err != nil {
+			return err
+		}
+		return txn.Delete(intentKey(blobOf(meta)))
+	})
+	if err != nil {
+		return nil, b.rollback(err, blob)
+	}
+	orphans := duplicateOf(blob, meta)
+	if orphan != "" {
+		orphans = append(orphans, orphan)
+	}
+	if err := b.resolveIntents(orphans...); err != nil {
+		return nil, err
+	}
+	return &Descriptor{meta: meta}, nil
+}
+
+// replaceMeta replaces the metadata cur of an
+// object with meta and updates the indexes.
+func (b Bucket) replaceMeta(txn storeTxn, cur, meta *Metadata) error {
+	if err := b.deleteIndexes(txn, cur); err != nil {
+		return err
+	}
+	data, err := meta.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := txn.Set(metaKey(meta.Get(MetaKeyID)), data); err != nil {
+		return err
+	}
+	return b.setIndexes(txn, meta)
+}
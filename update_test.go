@@ -0,0 +1,188 @@
+package objst
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestUpdateMeta(t *testing.T) {
+	o := tEnv.obj()
+	o.SetMetaKey("foo", "bar")
+	o.SetMetaKey("baz", "qux")
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	before, err := tEnv.b.Describe(o.ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	patch := map[MetaKey]string{
+		"foo":       "updated",
+		"baz":       "",
+		MetaKeyName: "ignored",
+	}
+	d, err := tEnv.b.UpdateMeta(o.ID(), patch, before.ETag())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if d.GetMetaKey("foo") != "updated" || d.HasMetaKey("baz") || d.Name() != o.Name() {
+		t.Fatalf("patch is not applied correctly. Got: %+v", d.ToModel())
+	}
+	if d.ETag() == before.ETag() {
+		t.Fatalf("etag should change with the update")
+	}
+	objs, err := tEnv.b.Execute(NewQuery().Owner(o.Owner()).Param("foo", "updated"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(objs) != 1 {
+		t.Fatalf("updated object should be found. Got: %d objects", len(objs))
+	}
+	if _, err := tEnv.b.UpdateMeta(o.ID(), patch, before.ETag()); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("stale etag should be rejected. Got: %v", err)
+	}
+}
+
+func TestUpdateMetaContentType(t *testing.T) {
+	o := tEnv.obj()
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	patch := map[MetaKey]string{MetaKeyContentType: ""}
+	if _, err := tEnv.b.UpdateMeta(o.ID(), patch, ""); !errors.Is(err, ErrContentTypeNotExist) {
+		t.Fatalf("content type should not be removed. Got: %v", err)
+	}
+	d, err := tEnv.b.Describe(o.ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if d.GetMetaKey(MetaKeyContentType) != o.GetMetaKey(MetaKeyContentType) {
+		t.Fatalf("content type should be kept. Got: %s", d.GetMetaKey(MetaKeyContentType))
+	}
+}
+
+func TestReplace(t *testing.T) {
+	o := tEnv.obj()
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	pl := tEnv.payload(20)
+	d, err := tEnv.b.Replace(o.ID(), bytes.NewReader(pl), "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if d.ID() != o.ID() || d.Size() != int64(len(pl)) {
+		t.Fatalf("replaced object is not correct. Got: %+v", d.ToModel())
+	}
+	got, err := tEnv.b.GetPayload(o.ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(got, pl) {
+		t.Fatalf("payload is not replaced. Got: %s. Expected: %s", got, pl)
+	}
+	if _, err := tEnv.b.blobs.Open(blobOf(o.meta)); err == nil {
+		t.Fatalf("previous payload should be removed")
+	}
+	if _, err := tEnv.b.Replace(o.ID(), bytes.NewReader(pl), "stale"); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("stale etag should be rejected. Got: %v", err)
+	}
+}
+
+func TestReplaceVersioned(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	if err := b.SetVersioning(true); err != nil {
+		t.Error(err)
+		return
+	}
+	id, pls := createVersions(t, b, tEnv.name(), tEnv.owner(), 1)
+	d, err := b.Replace(id, bytes.NewReader(tEnv.payload(10)), "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if d.Version() != 2 {
+		t.Fatalf("replace should create a new version. Got: %d", d.Version())
+	}
+	o, err := b.GetVersion(id, 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(o.Payload(), pls[0]) {
+		t.Fatalf("previous version should be kept")
+	}
+}
+
+func TestReplaceQuota(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	o := tEnv.obj()
+	if err := b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := b.SetQuota(o.Owner(), Quota{MaxBytes: 10}); err != nil {
+		t.Error(err)
+		return
+	}
+	// a smaller payload is accepted at the limit
+	if _, err := b.Replace(o.ID(), bytes.NewReader(tEnv.payload(5)), ""); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := b.SetQuota(o.Owner(), Quota{MaxBytes: 5}); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := b.SetVersioning(true); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := b.Replace(o.ID(), bytes.NewReader(tEnv.payload(5)), ""); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("quota should be exceeded. Got: %v", err)
+	}
+}
+
+func TestHTTPReplaceTooLarge(t *testing.T) {
+	o := tEnv.obj()
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	opts := DefaultHTTPHandlerOptions()
+	opts.MaxUploadSize = 5
+	hl := NewHTTPHandler(tEnv.b, opts)
+	target, err := url.JoinPath(tEnv.ts.URL, route, o.ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	w := httptest.NewRecorder()
+	hl.ServeHTTP(w, httptest.NewRequest(http.MethodPut, target, bytes.NewReader(tEnv.payload(20))))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("statuscode is not %d. Got: %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+	got, err := tEnv.b.GetPayload(o.ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(got, o.Payload()) {
+		t.Fatalf("payload should not be replaced")
+	}
+}
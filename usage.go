@@ -0,0 +1,244 @@
+package objst
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// prefixUsage is the prefix of the usage counters of the owners.
+	prefixUsage = "usage/"
+	// prefixQuota is the prefix of the quotas of the owners.
+	prefixQuota = "quota/"
+)
+
+func ownerUsageKey(owner string) []byte {
+	return []byte(prefixUsage + owner)
+}
+
+func quotaKey(owner string) []byte {
+	return []byte(prefixQuota + owner)
+}
+
+// Usage is the storage used by an owner. Bytes is the sum of
+// the payload sizes of all versions of the objects of the owner.
+type Usage struct {
+	Objects int64 `json:"objects"`
+	Bytes   int64 `json:"bytes"`
+}
+
+func (u Usage) add(delta Usage) Usage {
+	return Usage{Objects: u.Objects + delta.Objects, Bytes: u.Bytes + delta.Bytes}
+}
+
+func (u Usage) neg() Usage {
+	return Usage{Objects: -u.Objects, Bytes: -u.Bytes}
+}
+
+// Quota limits the storage an owner can use.
+// A limit of zero is unlimited.
+type Quota struct {
+	MaxObjects int64 `json:"maxObjects,omitempty"`
+	MaxBytes   int64 `json:"maxBytes,omitempty"`
+}
+
+func (q Quota) isValid() error {
+	if q.MaxObjects < 0 || q.MaxBytes < 0 {
+		return fmt.Errorf("%w: limits must not be negative", ErrInvalidQuota)
+	}
+	return nil
+}
+
+// check returns ErrQuotaExceeded if adding delta to u exceeds the
+// quota. Limits which are exceeded already don't prevent deltas
+// which aren't increasing the usage.
+func (q Quota) check(u, delta Usage) error {
+	if q.MaxObjects > 0 && delta.Objects > 0 && u.Objects+delta.Objects > q.MaxObjects {
+		return fmt.Errorf("%w: limit of %d objects", ErrQuotaExceeded, q.MaxObjects)
+	}
+	if q.MaxBytes > 0 && delta.Bytes > 0 && u.Bytes+delta.Bytes > q.MaxBytes {
+		return fmt.Errorf("%w: limit of %d bytes", ErrQuotaExceeded, q.MaxBytes)
+	}
+	return nil
+}
+
+// sizeOf returns the size of the payload of the version.
+func sizeOf(meta *Metadata) int64 {
+	size, _ := strconv.ParseInt(meta.Get(MetaKeySize), 10, 64)
+	return size
+}
+
+// objectUsage returns the usage of the object
+// including all noncurrent versions.
+func (b Bucket) objectUsage(txn storeTxn, meta *Metadata) (Usage, error) {
+	history, err := b.history(txn, meta.Get(MetaKeyID))
+	if err != nil {
+		return Usage{}, err
+	}
+	u := Usage{Objects: 1, Bytes: sizeOf(meta)}
+	for _, v := range history {
+		u.Bytes += sizeOf(v)
+	}
+	return u, nil
+}
+
+func getUsage(txn storeTxn, owner string) (Usage, error) {
+	u := Usage{}
+	val, err := txn.Get(ownerUsageKey(owner))
+	if errors.Is(err, errKeyNotFound) {
+		return u, nil
+	}
+	if err != nil {
+		return u, err
+	}
+	return u, json.Unmarshal(val, &u)
+}
+
+func setUsage(txn storeTxn, owner string, u Usage) error {
+	if u == (Usage{}) {
+		return txn.Delete(ownerUsageKey(owner))
+	}
+	data, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return txn.Set(ownerUsageKey(owner), data)
+}
+
+// getQuota returns the quota of the owner or
+// the default quota if the owner has none.
+func (b Bucket) getQuota(txn storeTxn, owner string) (Quota, error) {
+	q := Quota{}
+	val, err := txn.Get(quotaKey(owner))
+	if errors.Is(err, errKeyNotFound) {
+		return b.settings.defaultQuota(), nil
+	}
+	if err != nil {
+		return q, err
+	}
+	return q, json.Unmarshal(val, &q)
+}
+
+// charge adds delta to the usage of the owner. It fails
+// with ErrQuotaExceeded if the quota would be exceeded.
+func (b Bucket) charge(txn storeTxn, owner string, delta Usage) error {
+	u, err := getUsage(txn, owner)
+	if err != nil {
+		return err
+	}
+	q, err := b.getQuota(txn, owner)
+	if err != nil {
+		return err
+	}
+	if err := q.check(u, delta); err != nil {
+		return fmt.Errorf("%w of the owner %s", err, owner)
+	}
+	return setUsage(txn, owner, u.add(delta))
+}
+
+// refund subtracts delta from the usage of the owner.
+func refund(txn storeTxn, owner string, delta Usage) error {
+	u, err := getUsage(txn, owner)
+	if err != nil {
+		return err
+	}
+	return setUsage(txn, owner, u.add(delta.neg()))
+}
+
+// checkQuota fails early with ErrQuotaExceeded if adding
+// delta to the usage of the owner exceeds the quota.
+func (b Bucket) checkQuota(owner string, delta Usage) error {
+	return b.meta.View(func(txn storeTxn) error {
+		u, err := getUsage(txn, owner)
+		if err != nil {
+			return err
+		}
+		q, err := b.getQuota(txn, owner)
+		if err != nil {
+			return err
+		}
+		if err := q.check(u, delta); err != nil {
+			return fmt.Errorf("%w of the owner %s", err, owner)
+		}
+		return nil
+	})
+}
+
+// Usage returns the storage used by the owner.
+func (b Bucket) Usage(owner string) (Usage, error) {
+	var u Usage
+	err := b.meta.View(func(txn storeTxn) error {
+		res, err := getUsage(txn, owner)
+		u = res
+		return err
+	})
+	return u, err
+}
+
+// Usages returns the storage used by every owner
+// having at least one object e.g. for billing.
+func (b Bucket) Usages() (map[string]Usage, error) {
+	usages := make(map[string]Usage)
+	err := b.meta.View(func(txn storeTxn) error {
+		it := txn.Iterate([]byte(prefixUsage), false)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			val, err := it.Value()
+			if err != nil {
+				return err
+			}
+			u := Usage{}
+			if err := json.Unmarshal(val, &u); err != nil {
+				return err
+			}
+			usages[strings.TrimPrefix(string(it.Key()), prefixUsage)] = u
+		}
+		return nil
+	})
+	return usages, err
+}
+
+// SetQuota sets the quota of the owner replacing the default
+// quota. A zero quota resets the owner to the default quota.
+// Objects of owners exceeding their new quota are kept but
+// the usage of the owner can't grow anymore.
+func (b Bucket) SetQuota(owner string, q Quota) error {
+	if err := q.isValid(); err != nil {
+		return err
+	}
+	return b.meta.Update(func(txn storeTxn) error {
+		if q == (Quota{}) {
+			return txn.Delete(quotaKey(owner))
+		}
+		data, err := json.Marshal(q)
+		if err != nil {
+			return err
+		}
+		return txn.Set(quotaKey(owner), data)
+	})
+}
+
+// SetDefaultQuota sets the quota of all owners which don't
+// have a quota of their own. The setting is persisted.
+func (b Bucket) SetDefaultQuota(q Quota) error {
+	if err := q.isValid(); err != nil {
+		return err
+	}
+	return b.updateSettings(func(s *settings) {
+		s.DefaultQuota = q
+	})
+}
+
+// Quota returns the quota applying to the owner.
+func (b Bucket) Quota(owner string) (Quota, error) {
+	var q Quota
+	err := b.meta.View(func(txn storeTxn) error {
+		res, err := b.getQuota(txn, owner)
+		q = res
+		return err
+	})
+	return q, err
+}
@@ -0,0 +1,226 @@
+package objst
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestUsage(t *testing.T) {
+	owner := tEnv.owner()
+	objs := tEnv.nObj(2)
+	for _, o := range objs {
+		o.meta.set(MetaKeyOwner, owner)
+		if err := tEnv.b.Create(o); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	tests := []struct {
+		name string
+		fn   func() error
+		want Usage
+	}{
+		{
+			name: "create",
+			fn:   func() error { return nil },
+			want: Usage{Objects: 2, Bytes: 20},
+		},
+		{
+			name: "delete",
+			fn:   func() error { return tEnv.b.DeleteByID(objs[0].ID()) },
+			want: Usage{Objects: 1, Bytes: 10},
+		},
+		{
+			name: "transfer",
+			fn: func() error {
+				_, err := tEnv.b.Transfer(objs[1].ID(), tEnv.owner())
+				return err
+			},
+			want: Usage{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.fn(); err != nil {
+				t.Error(err)
+				return
+			}
+			u, err := tEnv.b.Usage(owner)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if u != tc.want {
+				t.Fatalf("usage is not correct. Got: %+v. Expected: %+v", u, tc.want)
+			}
+		})
+	}
+	d, err := tEnv.b.Describe(objs[1].ID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	u, err := tEnv.b.Usage(d.Owner())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if u != (Usage{Objects: 1, Bytes: 10}) {
+		t.Fatalf("usage should be moved to the new owner. Got: %+v", u)
+	}
+}
+
+func TestUsageVersions(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	if err := b.SetVersioning(true); err != nil {
+		t.Error(err)
+		return
+	}
+	owner := tEnv.owner()
+	id, _ := createVersions(t, b, tEnv.name(), owner, 3)
+	if err := b.DeleteVersion(id, 1); err != nil {
+		t.Error(err)
+		return
+	}
+	u, err := b.Usage(owner)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if u != (Usage{Objects: 1, Bytes: 20}) {
+		t.Fatalf("noncurrent versions should be accounted. Got: %+v", u)
+	}
+	if err := b.DeleteByID(id); err != nil {
+		t.Error(err)
+		return
+	}
+	usages, err := b.Usages()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, ok := usages[owner]; ok {
+		t.Fatalf("usage of the owner should be removed. Got: %+v", usages[owner])
+	}
+}
+
+func TestQuota(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	owner := tEnv.owner()
+	tests := []struct {
+		name  string
+		quota Quota
+	}{
+		{
+			name:  "objects",
+			quota: Quota{MaxObjects: 1},
+		},
+		{
+			name:  "bytes",
+			quota: Quota{MaxBytes: 15},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := b.SetQuota(owner, tc.quota); err != nil {
+				t.Error(err)
+				return
+			}
+			o := tEnv.obj()
+			o.meta.set(MetaKeyOwner, owner)
+			if err := b.Create(o); err != nil {
+				t.Error(err)
+				return
+			}
+			defer b.DeleteByID(o.ID())
+			exceeding := tEnv.obj()
+			exceeding.meta.set(MetaKeyOwner, owner)
+			if err := b.Create(exceeding); !errors.Is(err, ErrQuotaExceeded) {
+				t.Fatalf("quota should be exceeded. Got: %v", err)
+			}
+			if _, err := b.GetByName(exceeding.Name(), owner); err == nil {
+				t.Fatalf("exceeding object should not be created")
+			}
+		})
+	}
+	if err := b.SetQuota(owner, Quota{}); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := b.SetDefaultQuota(Quota{MaxBytes: 5}); err != nil {
+		t.Error(err)
+		return
+	}
+	q, err := b.Quota(owner)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if q != (Quota{MaxBytes: 5}) {
+		t.Fatalf("default quota should apply. Got: %+v", q)
+	}
+	if err := b.Create(tEnv.obj()); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("default quota should be exceeded. Got: %v", err)
+	}
+	if err := b.SetQuota(owner, Quota{MaxObjects: -1}); !errors.Is(err, ErrInvalidQuota) {
+		t.Fatalf("negative quota should be rejected. Got: %v", err)
+	}
+}
+
+func TestHTTPUsage(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	owner := tEnv.owner()
+	if err := b.SetQuota(owner, Quota{MaxBytes: 1}); err != nil {
+		t.Error(err)
+		return
+	}
+	injectOwner := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), CtxKeyOwner, owner)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+	hl := injectOwner(NewHTTPHandler(b, DefaultHTTPHandlerOptions()))
+	target, err := url.JoinPath(tEnv.ts.URL, route, "upload")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	r, err := tEnv.newUploadRequest(target, nil, tEnv.h.opts.FormKey, "testdata/images/2500KB.jpg")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	w := httptest.NewRecorder()
+	hl.ServeHTTP(w, r)
+	if w.Code != http.StatusInsufficientStorage {
+		t.Fatalf("statuscode is not %d. Got: %d", http.StatusInsufficientStorage, w.Code)
+	}
+	target, err = url.JoinPath(tEnv.ts.URL, route, "usage")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	w = httptest.NewRecorder()
+	hl.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("statuscode is not %d. Got: %d", http.StatusOK, w.Code)
+	}
+	m := usageModel{}
+	if err := json.NewDecoder(w.Body).Decode(&m); err != nil {
+		t.Error(err)
+		return
+	}
+	want := usageModel{Owner: owner, Quota: Quota{MaxBytes: 1}}
+	if m != want {
+		t.Fatalf("usage model is not correct. Got: %+v. Expected: %+v", m, want)
+	}
+}
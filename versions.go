@@ -0,0 +1,233 @@
+package objst
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// versionOf returns the version of the object.
+func versionOf(meta *Metadata) int {
+	v, _ := strconv.Atoi(meta.Get(MetaKeyVersion))
+	return v
+}
+
+// Versions returns the descriptors of all versions of the
+// object ordered from the oldest to the current version.
+func (b Bucket) Versions(id string) ([]*Descriptor, error) {
+	var ds []*Descriptor
+	err := b.meta.View(func(txn storeTxn) error {
+		cur, err := b.getMeta(txn, id)
+		if err != nil {
+			return err
+		}
+		history, err := b.history(txn, id)
+		if err != nil {
+			return err
+		}
+		ds = make([]*Descriptor, 0, len(history)+1)
+		for _, meta := range append(history, cur) {
+			ds = append(ds, &Descriptor{meta: meta})
+		}
+		return nil
+	})
+	return ds, err
+}
+
+// DescribeVersion returns the descriptor of the given version of the object.
+func (b Bucket) DescribeVersion(id string, version int) (*Descriptor, error) {
+	meta, err := b.getVersionMeta(id, version)
+	if err != nil {
+		return nil, err
+	}
+	return &Descriptor{meta: meta}, nil
+}
+
+// GetVersion returns the given version of the object including its payload.
+func (b Bucket) GetVersion(id string, version int) (*Object, error) {
+	meta, err := b.getVersionMeta(id, version)
+	if err != nil {
+		return nil, err
+	}
+	return b.composeObject(meta)
+}
+
+// OpenVersion returns a reader streaming the payload of the
+// given version of the object. The reader has to be closed.
+func (b Bucket) OpenVersion(id string, version int) (io.ReadCloser, error) {
+	meta, err := b.getVersionMeta(id, version)
+	if err != nil {
+		return nil, err
+	}
+	return b.openPayload(meta)
+}
+
+// RestoreVersion makes the given version the current version of
+// the object by creating a new version with the payload and user
+// defined metadata of the restored version. The name and owner of
+// the object are kept. The returned descriptor is the new version.
+func (b Bucket) RestoreVersion(id string, version int) (*Descriptor, error) {
+	var meta *Metadata
+	err := b.meta.Update(func(txn storeTxn) error {
+		cur, err := b.getMeta(txn, id)
+		if err != nil {
+			return err
+		}
+		if versionOf(cur) == version {
+			meta = cur
+			return nil
+		}
+		old, err := b.getVersion(txn, id, version)
+		if err != nil {
+			return err
+		}
+		meta = old.clone()
+		meta.set(MetaKeyName, cur.Get(MetaKeyName))
+		meta.set(MetaKeyOwner, cur.Get(MetaKeyOwner))
+		meta.set(MetaKeyUpdatedAt, formatTime(time.Now()))
+		if err := b.archiveVersion(txn, id, meta); err != nil {
+			return err
+		}
+		// the payload is shared with the restored version
+		if err := retainBlob(txn, blobOf(meta)); err != nil {
+			return err
+		}
+		if err := b.charge(txn, cur.Get(MetaKeyOwner), Usage{Bytes: sizeOf(meta)}); err != nil {
+			return err
+		}
+		return b.replaceMeta(txn, cur, meta)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Descriptor{meta: meta}, nil
+}
+
+// DeleteVersion removes a noncurrent version of the object. The
+// payload of the version is removed iff no other version or
+// object references it. The current version can only be removed
+// by deleting the object.
+func (b Bucket) DeleteVersion(id string, version int) error {
+	var orphan string
+	err := b.meta.Update(func(txn storeTxn) error {
+		cur, err := b.getMeta(txn, id)
+		if err != nil {
+			return err
+		}
+		if versionOf(cur) == version {
+			return fmt.Errorf("%w: %d", ErrVersionIsCurrent, version)
+		}
+		old, err := b.getVersion(txn, id, version)
+		if err != nil {
+			return err
+		}
+		if err := txn.Delete(versionKey(id, version)); err != nil {
+			return err
+		}
+		if err := refund(txn, cur.Get(MetaKeyOwner), Usage{Bytes: sizeOf(old)}); err != nil {
+			return err
+		}
+		isOrphan, err := releaseBlob(txn, old)
+		if isOrphan {
+			orphan = blobOf(old)
+		}
+		return err
+	})
+	if err != nil || orphan == "" {
+		return err
+	}
+	return b.resolveIntent(orphan)
+}
+
+// archiveVersion moves the current version of the object
+// to the history and prepares meta to become the next
+// version of the object. The indexes of the current
+// version are removed.
+func (b Bucket) archiveVersion(txn storeTxn, id string, meta *Metadata) error {
+	cur, err := b.getMeta(txn, id)
+	if err != nil {
+		return err
+	}
+	data, err := cur.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := txn.Set(versionKey(id, versionOf(cur)), data); err != nil {
+		return err
+	}
+	if err := b.deleteIndexes(txn, cur); err != nil {
+		return err
+	}
+	meta.set(MetaKeyID, id)
+	meta.set(MetaKeyVersion, strconv.Itoa(versionOf(cur)+1))
+	meta.set(MetaKeyCreatedAt, cur.Get(MetaKeyCreatedAt))
+	return nil
+}
+
+// getVersionMeta returns the metadata of the given version of the object.
+func (b Bucket) getVersionMeta(id string, version int) (*Metadata, error) {
+	var meta *Metadata
+	err := b.meta.View(func(txn storeTxn) error {
+		cur, err := b.getMeta(txn, id)
+		if err != nil {
+			return err
+		}
+		if versionOf(cur) == version {
+			meta = cur
+			return nil
+		}
+		meta, err = b.getVersion(txn, id, version)
+		return err
+	})
+	return meta, err
+}
+
+// getVersion returns the metadata of a noncurrent version of the object.
+func (b Bucket) getVersion(txn storeTxn, id string, version int) (*Metadata, error) {
+	val, err := txn.Get(versionKey(id, version))
+	if errors.Is(err, errKeyNotFound) {
+		return nil, fmt.Errorf("%w: version %d of %s: %w", ErrVersionNotFound, version, id, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	meta := NewMetadata()
+	return meta, meta.Unmarshal(val)
+}
+
+// history returns the metadata of all noncurrent versions
+// of the object ordered from the oldest to the newest.
+func (b Bucket) history(txn storeTxn, id string) ([]*Metadata, error) {
+	metas := make([]*Metadata, 0)
+	it := txn.Iterate(versionPrefix(id), false)
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		val, err := it.Value()
+		if err != nil {
+			return nil, err
+		}
+		meta := NewMetadata()
+		if err := meta.Unmarshal(val); err != nil {
+			return nil, err
+		}
+		metas = append(metas, meta)
+	}
+	return metas, nil
+}
+
+// deleteVersions removes all noncurrent versions of the object
+// and returns their metadata.
+func (b Bucket) deleteVersions(txn storeTxn, id string) ([]*Metadata, error) {
+	metas, err := b.history(txn, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, meta := range metas {
+		if err := txn.Delete(versionKey(id, versionOf(meta))); err != nil {
+			return nil, err
+		}
+	}
+	return metas, nil
+}
@@ -0,0 +1,178 @@
+package objst
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// createVersions creates n versions of the
+// same object and returns their payloads.
+func createVersions(t *testing.T, b *Bucket, name, owner string, n int) (string, [][]byte) {
+	var id string
+	pls := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		o, _ := NewObject(name, owner)
+		pl := tEnv.payload(10)
+		o.Write(pl)
+		if err := b.Create(o); err != nil {
+			t.Fatal(err)
+		}
+		if id != "" && o.ID() != id {
+			t.Fatalf("id of the object should be stable. Got: %s. Expected: %s", o.ID(), id)
+		}
+		id = o.ID()
+		pls = append(pls, pl)
+	}
+	return id, pls
+}
+
+func TestVersions(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	if err := b.SetVersioning(true); err != nil {
+		t.Error(err)
+		return
+	}
+	id, pls := createVersions(t, b, tEnv.name(), tEnv.owner(), 3)
+	ds, err := b.Versions(id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(ds) != 3 {
+		t.Fatalf("expected 3 versions. Got: %d", len(ds))
+	}
+	for i, d := range ds {
+		if d.Version() != i+1 {
+			t.Fatalf("versions are not ordered. Got: %d. Expected: %d", d.Version(), i+1)
+		}
+		if !d.CreatedAt().Equal(ds[0].CreatedAt()) {
+			t.Fatalf("createdAt should be kept between versions")
+		}
+		o, err := b.GetVersion(id, d.Version())
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !bytes.Equal(o.Payload(), pls[i]) {
+			t.Fatalf("payload of version %d is not correct", d.Version())
+		}
+	}
+	// only the current version should be found by queries
+	objs, err := b.Execute(NewQuery().Owner(ds[0].Owner()))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(objs) != 1 || !bytes.Equal(objs[0].Payload(), pls[2]) {
+		t.Fatalf("query should only return the current version. Got: %d objects", len(objs))
+	}
+	if _, err := b.GetVersion(id, 4); !errors.Is(err, ErrVersionNotFound) {
+		t.Fatalf("missing version should not be found. Got: %v", err)
+	}
+}
+
+func TestNameConflictWithoutVersioning(t *testing.T) {
+	o := tEnv.obj()
+	if err := tEnv.b.Create(o); err != nil {
+		t.Error(err)
+		return
+	}
+	dup, _ := NewObject(o.Name(), o.Owner())
+	dup.Write(tEnv.payload(10))
+	if err := tEnv.b.Create(dup); !errors.Is(err, ErrNameConflict) {
+		t.Fatalf("name should be unique without versioning. Got: %v", err)
+	}
+}
+
+func TestRestoreVersion(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	if err := b.SetVersioning(true); err != nil {
+		t.Error(err)
+		return
+	}
+	id, pls := createVersions(t, b, tEnv.name(), tEnv.owner(), 2)
+	d, err := b.RestoreVersion(id, 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if d.Version() != 3 {
+		t.Fatalf("restored version should be the next version. Got: %d", d.Version())
+	}
+	pl, err := b.GetPayload(id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(pl, pls[0]) {
+		t.Fatalf("payload of the restored version is not correct")
+	}
+	// the payload is shared with the restored version
+	if err := b.DeleteVersion(id, 1); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := b.GetPayload(id); err != nil {
+		t.Fatalf("payload of the current version should be kept. Got: %v", err)
+	}
+}
+
+func TestDeleteVersion(t *testing.T) {
+	b, _ := newTempBucket(t, nil)
+	defer b.Shutdown()
+	if err := b.SetVersioning(true); err != nil {
+		t.Error(err)
+		return
+	}
+	id, _ := createVersions(t, b, tEnv.name(), tEnv.owner(), 2)
+	if err := b.DeleteVersion(id, 2); !errors.Is(err, ErrVersionIsCurrent) {
+		t.Fatalf("current version should not be deletable. Got: %v", err)
+	}
+	old, err := b.getVersionMeta(id, 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := b.DeleteVersion(id, 1); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := b.blobs.Open(blobOf(old)); err == nil {
+		t.Fatalf("payload of the deleted version should be removed")
+	}
+	ds, err := b.Versions(id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(ds) != 1 {
+		t.Fatalf("expected 1 version. Got: %d", len(ds))
+	}
+	if err := b.DeleteByID(id); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := b.Versions(id); err == nil {
+		t.Fatalf("versions should be removed with the object")
+	}
+}
+
+func TestVersioningIsPersisted(t *testing.T) {
+	b, dir := newTempBucket(t, nil)
+	if err := b.SetVersioning(true); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := b.Shutdown(); err != nil {
+		t.Error(err)
+		return
+	}
+	b = reopenTempBucket(t, dir, nil)
+	defer b.Shutdown()
+	if !b.IsVersioned() {
+		t.Fatalf("versioning should be persisted")
+	}
+}